@@ -0,0 +1,379 @@
+// cli.go
+//
+// local cli — replay fixtures, send single findings, render dry runs and
+// generate fixtures from guardduty sample findings.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	gdtypes "github.com/aws/aws-sdk-go-v2/service/guardduty/types"
+	"github.com/joho/godotenv"
+
+	guarddutyslack "github.com/cruxstack/aws-guardduty-slack-integration-go"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/logging"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/tracing"
+)
+
+const cliUsage = `usage: guardduty-slack <command> [flags]
+
+commands:
+  samples            replay fixtures/samples.json
+  send               post findings from a file (event, event array or bare finding)
+  generate-fixture   create guardduty sample findings and write them as fixtures
+  validate-config    check the APP_* config, slack token and channel access
+
+run "guardduty-slack <command> -h" for command flags.
+`
+
+func RunCLI(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, cliUsage)
+		return errors.New("missing command")
+	}
+
+	if _, err := os.Stat(".env"); err == nil {
+		godotenv.Load(".env")
+	}
+
+	switch args[0] {
+	case "samples":
+		return runSend(ctx, "samples", args[1:], filepath.Join("fixtures", "samples.json"))
+	case "send":
+		return runSend(ctx, "send", args[1:], "")
+	case "generate-fixture":
+		return runGenerateFixture(ctx, args[1:])
+	case "validate-config":
+		return runValidateConfig(ctx, args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, cliUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, cliUsage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// ------------------------------------------------------------------ send ---
+
+func runSend(ctx context.Context, name string, args []string, defaultFile string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("file", defaultFile, "path to the finding json")
+	dryRun := fs.Bool("dry-run", false, "print the rendered block kit json instead of posting")
+	concurrency := fs.Int("concurrency", 0, "findings processed in parallel (default: APP_MAX_CONCURRENCY)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("missing -file")
+	}
+
+	cfg, err := guarddutyslack.LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.DryRun = cfg.DryRun || *dryRun
+	if *concurrency > 0 {
+		cfg.MaxConcurrency = *concurrency
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.TracingEnabled {
+		tp, err := tracing.Setup(ctx)
+		if err != nil {
+			return err
+		}
+		defer tp.Shutdown(context.WithoutCancel(ctx))
+	}
+
+	// keep stdout clean for dry-run payloads
+	app, err := guarddutyslack.NewApp(ctx, cfg,
+		guarddutyslack.WithLogger(logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)),
+		guarddutyslack.WithDryRunOutput(os.Stdout),
+	)
+	if err != nil {
+		return err
+	}
+
+	return ProcessSamples(ctx, app, *file)
+}
+
+func ProcessSamples(ctx context.Context, a *guarddutyslack.App, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	events, err := ReadEvents(data)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	var errs []error
+	for i, err := range a.HandleEvents(ctx, events) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("process id=%s: %w", events[i].ID, err))
+		}
+	}
+	if len(errs) > 0 {
+		a.Logger().Warn("replay completed with failures", "events", len(events), "failed", len(errs))
+	}
+	return errors.Join(errs...)
+}
+
+// ReadEvents accepts a single eventbridge event, an array of events, or bare
+// finding details (as returned by the guardduty api) and returns them as
+// events.
+func ReadEvents(data []byte) ([]events.CloudWatchEvent, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		items = []json.RawMessage{data}
+	}
+
+	out := make([]events.CloudWatchEvent, 0, len(items))
+	for i, item := range items {
+		var evt events.CloudWatchEvent
+		if err := json.Unmarshal(item, &evt); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		if len(evt.Detail) == 0 {
+			evt = events.CloudWatchEvent{
+				DetailType: "GuardDuty Finding",
+				Source:     "aws.guardduty",
+				Detail:     item,
+			}
+		}
+		out = append(out, evt)
+	}
+	return out, nil
+}
+
+// -------------------------------------------------------- validate-config ---
+
+// runValidateConfig reports every config and slack access problem at once.
+func runValidateConfig(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := guarddutyslack.LoadConfig()
+	if err != nil {
+		return err
+	}
+	app, err := guarddutyslack.NewApp(ctx, cfg,
+		guarddutyslack.WithLogger(logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)),
+	)
+	if err == nil {
+		err = app.Check(ctx)
+	} else {
+		err = errors.Join(cfg.Validate(), err)
+	}
+	if err == nil {
+		fmt.Fprintln(os.Stdout, "config ok")
+		return nil
+	}
+
+	problems := strings.Split(err.Error(), "\n")
+	for _, p := range problems {
+		fmt.Fprintln(os.Stdout, "✗ "+p)
+	}
+	return fmt.Errorf("config check failed: %d problem(s)", len(problems))
+}
+
+// ------------------------------------------------------- generate-fixture ---
+
+func runGenerateFixture(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("generate-fixture", flag.ContinueOnError)
+	detectorID := fs.String("detector-id", "", "guardduty detector id (default: first detector in the region)")
+	out := fs.String("out", "", "output file (default: stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: guardduty-slack generate-fixture [flags] <finding-type>...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	findingTypes := fs.Args()
+	if len(findingTypes) == 0 {
+		fs.Usage()
+		return errors.New("missing finding type")
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("load aws config: %w", err)
+	}
+	client := guardduty.NewFromConfig(awsCfg)
+
+	if *detectorID == "" {
+		detectors, err := client.ListDetectors(ctx, &guardduty.ListDetectorsInput{})
+		if err != nil {
+			return fmt.Errorf("list detectors: %w", err)
+		}
+		if len(detectors.DetectorIds) == 0 {
+			return fmt.Errorf("no guardduty detector in %s", awsCfg.Region)
+		}
+		*detectorID = detectors.DetectorIds[0]
+	}
+
+	_, err = client.CreateSampleFindings(ctx, &guardduty.CreateSampleFindingsInput{
+		DetectorId:   detectorID,
+		FindingTypes: findingTypes,
+	})
+	if err != nil {
+		return fmt.Errorf("create sample findings: %w", err)
+	}
+
+	findings, err := fetchSampleFindings(ctx, client, *detectorID, findingTypes)
+	if err != nil {
+		return err
+	}
+
+	var fixtures []events.CloudWatchEvent
+	for _, f := range findings {
+		evt, err := fixtureEvent(f)
+		if err != nil {
+			return err
+		}
+		fixtures = append(fixtures, evt)
+	}
+
+	data, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0o644)
+}
+
+// fetchSampleFindings returns the most recent finding of each type. sample
+// findings take a few seconds to become listable, so lookups are retried.
+func fetchSampleFindings(ctx context.Context, client *guardduty.Client, detectorID string, findingTypes []string) ([]gdtypes.Finding, error) {
+	for attempt := 0; attempt < 10; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(3 * time.Second):
+			}
+		}
+
+		var ids []string
+		for _, t := range findingTypes {
+			list, err := client.ListFindings(ctx, &guardduty.ListFindingsInput{
+				DetectorId: aws.String(detectorID),
+				FindingCriteria: &gdtypes.FindingCriteria{
+					Criterion: map[string]gdtypes.Condition{"type": {Equals: []string{t}}},
+				},
+				SortCriteria: &gdtypes.SortCriteria{
+					AttributeName: aws.String("updatedAt"),
+					OrderBy:       gdtypes.OrderByDesc,
+				},
+				MaxResults: aws.Int32(1),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("list findings type=%s: %w", t, err)
+			}
+			ids = append(ids, list.FindingIds...)
+		}
+		if len(ids) < len(findingTypes) {
+			continue
+		}
+
+		out, err := client.GetFindings(ctx, &guardduty.GetFindingsInput{
+			DetectorId: aws.String(detectorID),
+			FindingIds: ids,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get findings: %w", err)
+		}
+		return out.Findings, nil
+	}
+	return nil, errors.New("timed out waiting for sample findings")
+}
+
+// fixtureEvent wraps an api finding in an eventbridge envelope. the sdk
+// serializes fields in go casing, so keys are converted to the camelCase used
+// by eventbridge payloads.
+func fixtureEvent(f gdtypes.Finding) (events.CloudWatchEvent, error) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return events.CloudWatchEvent{}, err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return events.CloudWatchEvent{}, err
+	}
+	detail, err := json.Marshal(camelizeKeys(generic))
+	if err != nil {
+		return events.CloudWatchEvent{}, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return events.CloudWatchEvent{}, err
+	}
+	hexID := hex.EncodeToString(id)
+
+	return events.CloudWatchEvent{
+		Version:    "0",
+		ID:         fmt.Sprintf("%s-%s-%s-%s-%s", hexID[0:8], hexID[8:12], hexID[12:16], hexID[16:20], hexID[20:]),
+		DetailType: "GuardDuty Finding",
+		Source:     "aws.guardduty",
+		AccountID:  aws.ToString(f.AccountId),
+		Time:       time.Now().UTC().Truncate(time.Second),
+		Region:     aws.ToString(f.Region),
+		Resources:  []string{},
+		Detail:     detail,
+	}, nil
+}
+
+// camelizeKeys lowercases the first letter of every object key and drops
+// null values left behind by unset sdk pointers.
+func camelizeKeys(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			if val == nil {
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(k)
+			out[string(unicode.ToLower(r))+k[size:]] = camelizeKeys(val)
+		}
+		return out
+	case []any:
+		for i := range t {
+			t[i] = camelizeKeys(t[i])
+		}
+		return t
+	default:
+		return v
+	}
+}
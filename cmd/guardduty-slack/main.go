@@ -0,0 +1,34 @@
+// main.go
+//
+// guardduty-to-slack — forward guardduty findings to slack
+// runs as the lambda handler when AWS_LAMBDA_FUNCTION_NAME is set, otherwise
+// as a local cli (see cli.go). configuration is read from APP_* env vars; see
+// the README for the full list.
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/handler"
+)
+
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
+}
+
+func main() {
+	if _, ok := os.LookupEnv("AWS_LAMBDA_FUNCTION_NAME"); ok {
+		lambda.Start(handler.New().Invoke)
+		return
+	}
+
+	if err := RunCLI(context.Background(), os.Args[1:]); err != nil {
+		fatal(err)
+	}
+}
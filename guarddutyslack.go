@@ -0,0 +1,892 @@
+// guarddutyslack.go
+//
+// app core and public api. the lambda entrypoint and local cli live in
+// cmd/guardduty-slack.
+
+// Package guarddutyslack parses GuardDuty findings (and Inspector2 findings
+// and AWS Health events) delivered by EventBridge, enriches them and hands
+// them to a Notifier (Slack by default).
+package guarddutyslack
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/slack-go/slack"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/accounts"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/ack"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/archive"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/config"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/correlate"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/dedupe"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/digest"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/escalation"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/knowledge"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/logging"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/metrics"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/routing"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/salvage"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/slackout"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/summary"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/throttle"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/tracing"
+)
+
+type (
+	Config        = config.Config
+	Finding       = finding.Finding
+	SeverityLevel = finding.SeverityLevel
+	SlackPoster   = slackout.SlackPoster
+)
+
+// BuildConfig loads the config from APP_* environment variables and validates
+// it.
+func BuildConfig() (Config, error) {
+	return config.Build()
+}
+
+// LoadConfig parses APP_* environment variables without validating them.
+func LoadConfig() (Config, error) {
+	return config.Load()
+}
+
+// ParseFinding decodes an eventbridge event detail into a Finding.
+func ParseFinding(raw json.RawMessage, consoleBase string) (Finding, error) {
+	return finding.Parse(raw, consoleBase)
+}
+
+// Notifier delivers a parsed and enriched finding.
+type Notifier interface {
+	Notify(ctx context.Context, f Finding) error
+}
+
+// ------------------------------------------------------------------ options ---
+
+type options struct {
+	logger    *slog.Logger
+	notifier  Notifier
+	poster    SlackPoster
+	dryRunOut io.Writer
+}
+
+type Option func(*options)
+
+// WithLogger replaces the default json logger on stdout.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) { o.logger = l }
+}
+
+// WithNotifier replaces the built-in slack notifier.
+func WithNotifier(n Notifier) Option {
+	return func(o *options) { o.notifier = n }
+}
+
+// WithSlackPoster replaces the slack api client used by the built-in
+// notifier and the digest, e.g. with a fake in tests.
+func WithSlackPoster(p SlackPoster) Option {
+	return func(o *options) { o.poster = p }
+}
+
+// WithDryRunOutput sets where dry-run payloads are written. by default they
+// are logged at info level.
+func WithDryRunOutput(w io.Writer) Option {
+	return func(o *options) { o.dryRunOut = w }
+}
+
+// --------------------------------------------------------------------- app ---
+
+type App struct {
+	cfg      Config
+	log      *slog.Logger
+	metrics  *metrics.Metrics
+	poster   SlackPoster
+	slack    *slack.Client
+	routes   routing.Rules
+	dests    routing.Destinations
+	notifier Notifier
+	digest   digest.Store
+	accounts accounts.Resolver
+	context  accounts.Describer
+	dedupe   dedupe.Store
+	escalate escalation.Escalator
+	summary  *summary.Summary
+	archive  archive.Archiver
+	park     salvage.Parker
+	floods   *throttle.Floods
+
+	// destinations get their own digests, storm messages and summary.
+	destinations []*destination
+}
+
+func NewApp(ctx context.Context, cfg Config, opts ...Option) (*App, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.logger == nil {
+		o.logger = logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	}
+
+	a := &App{
+		cfg:     cfg,
+		log:     o.logger,
+		metrics: metrics.New(os.Stdout, cfg.MetricsNamespace, cfg.MetricsEnabled),
+		poster:  o.poster,
+	}
+	var httpClient *http.Client
+	if a.poster == nil {
+		var err error
+		if httpClient, err = slackout.NewHTTPClient(ctx, cfg); err != nil {
+			return nil, err
+		}
+		a.slack = slack.New(cfg.SlackToken, slack.OptionHTTPClient(httpClient))
+		a.poster = slackout.NewPoster(a.slack, a.log, cfg.DryRun, o.dryRunOut)
+	}
+
+	awsConfig := sync.OnceValues(func() (aws.Config, error) {
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return aws.Config{}, fmt.Errorf("load aws config: %w", err)
+		}
+		return awsCfg, nil
+	})
+
+	a.notifier = o.notifier
+	if a.notifier == nil {
+		tmpl, err := slackout.LoadTemplate(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		runbooks, err := knowledge.LoadRunbooks(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if a.routes, err = routing.LoadRules(ctx, cfg); err != nil {
+			return nil, err
+		}
+		if a.dests, err = routing.LoadDestinations(ctx, cfg); err != nil {
+			return nil, err
+		}
+		if a.dests.UsesTags() && !slices.Contains(cfg.AccountContext, "organizations") {
+			return nil, errors.New("destinations select by tag: requires APP_ACCOUNT_CONTEXT=organizations")
+		}
+		var targets []slackout.Destination
+		for _, d := range a.dests {
+			dest := &destination{Destination: d, poster: a.poster, client: a.slack}
+			// a custom poster stands in for every workspace.
+			if d.Token != "" && d.Token != cfg.SlackToken && httpClient != nil {
+				dest.client = slack.New(d.Token, slack.OptionHTTPClient(httpClient))
+				dest.poster = slackout.NewPoster(dest.client, a.log.With("destination", d.Name), cfg.DryRun, o.dryRunOut)
+			}
+			a.destinations = append(a.destinations, dest)
+			targets = append(targets, slackout.Destination{Destination: d, Poster: dest.poster})
+		}
+		var threads correlate.Store
+		if cfg.CorrelationWindow > 0 {
+			threads = correlate.NewMemory(cfg.CorrelationWindow)
+			// like the idempotency table, a shadow deployment keeps to itself.
+			if cfg.CorrelationTable != "" && !cfg.DryRun {
+				awsCfg, err := awsConfig()
+				if err != nil {
+					return nil, err
+				}
+				threads = correlate.NewDynamoDB(dynamodb.NewFromConfig(awsCfg), cfg.CorrelationTable, cfg.CorrelationWindow)
+			}
+		}
+		a.notifier = slackout.NewNotifier(cfg, a.log, a.poster, tmpl, runbooks, a.routes, targets, threads)
+	}
+
+	if cfg.DigestQueueURL != "" {
+		awsCfg, err := awsConfig()
+		if err != nil {
+			return nil, err
+		}
+		a.digest = digest.NewSQSStore(sqs.NewFromConfig(awsCfg), cfg.DigestQueueURL)
+	}
+
+	switch cfg.EscalationBackend {
+	case escalation.BackendPagerDuty:
+		a.escalate = escalation.NewPagerDuty(cfg.PagerDutyRoutingKey)
+	case escalation.BackendOpsgenie:
+		a.escalate = escalation.NewOpsgenie(cfg.OpsgenieAPIKey, cfg.OpsgenieAPIURL)
+	}
+
+	dedupers := dedupe.Chain{dedupe.NewMemory(cfg.IdempotencyTTL, dedupe.DefaultCapacity)}
+	// a shadow deployment must not claim findings in the shared table, or the
+	// live function would skip them as duplicates.
+	if cfg.IdempotencyTable != "" && !cfg.DryRun {
+		awsCfg, err := awsConfig()
+		if err != nil {
+			return nil, err
+		}
+		dedupers = append(dedupers, dedupe.NewDynamoDB(
+			dynamodb.NewFromConfig(awsCfg), cfg.IdempotencyTable, cfg.IdempotencyTTL,
+		))
+	}
+	a.dedupe = dedupers
+
+	var resolvers accounts.Chain
+	if len(cfg.AccountMap) > 0 {
+		resolvers = append(resolvers, accounts.Static(cfg.AccountMap))
+	}
+	if cfg.AccountLookup == "organizations" {
+		awsCfg, err := awsConfig()
+		if err != nil {
+			return nil, err
+		}
+		resolvers = append(resolvers, accounts.NewOrganizations(
+			organizations.NewFromConfig(awsCfg), cfg.AccountCacheTTL,
+		))
+	}
+	if len(resolvers) > 0 {
+		a.accounts = resolvers
+	}
+
+	var describers accounts.DescriberChain
+	for _, src := range cfg.AccountContext {
+		awsCfg, err := awsConfig()
+		if err != nil {
+			return nil, err
+		}
+		switch src {
+		case "organizations":
+			describers = append(describers, accounts.NewOrganizationsContext(
+				organizations.NewFromConfig(awsCfg), cfg.AccountTeamTag, a.dests.UsesTags(), cfg.AccountCacheTTL,
+			))
+		case "guardduty":
+			describers = append(describers, accounts.NewGuardDutyMembers(
+				guardduty.NewFromConfig(awsCfg), cfg.GuardDutyDetectorID, cfg.AccountCacheTTL,
+			))
+		}
+	}
+	if len(describers) > 0 {
+		a.context = describers
+	}
+
+	if cfg.ArchiveBucket != "" {
+		awsCfg, err := awsConfig()
+		if err != nil {
+			return nil, err
+		}
+		a.archive = archive.NewS3(s3.NewFromConfig(awsCfg), cfg.ArchiveBucket, cfg.ArchivePrefix)
+	}
+
+	if cfg.ParkUnparseable {
+		// a shadow deployment must not drain into the shared queue; its
+		// poster writes the slack message to the dry-run output instead.
+		if cfg.ParkQueueURL != "" && !cfg.DryRun {
+			awsCfg, err := awsConfig()
+			if err != nil {
+				return nil, err
+			}
+			a.park = salvage.NewSQS(sqs.NewFromConfig(awsCfg), cfg.ParkQueueURL)
+		} else {
+			a.park = salvage.NewSlack(a.log, a.poster, cfg.SlackChannel)
+		}
+	}
+
+	// like the summary, the storm message is edited in place. each channel a
+	// finding can be posted to counts its own findings and gets its own storm
+	// message, created on its first finding.
+	if cfg.FloodThreshold > 0 && a.slack != nil && !cfg.DryRun {
+		newStore := func(string) throttle.Store { return throttle.NewMemory() }
+		if cfg.FloodTable != "" {
+			awsCfg, err := awsConfig()
+			if err != nil {
+				return nil, err
+			}
+			client := dynamodb.NewFromConfig(awsCfg)
+			newStore = func(channel string) throttle.Store {
+				return throttle.NewDynamoDB(client, cfg.FloodTable, channel, cfg.FloodWindow)
+			}
+		}
+		a.floods = throttle.NewFloods(newStore, cfg.FloodThreshold, cfg.FloodWindow)
+	}
+
+	// the summary needs the real client to edit and pin; shadow deployments
+	// leave the shared message alone. destinations pin theirs in their channel.
+	if cfg.SummaryTable != "" && a.slack != nil && !cfg.DryRun {
+		awsCfg, err := awsConfig()
+		if err != nil {
+			return nil, err
+		}
+		client := dynamodb.NewFromConfig(awsCfg)
+		summaries := map[string]*summary.Summary{}
+		newSummary := func(log *slog.Logger, api summary.SlackAPI, channel string) *summary.Summary {
+			if _, ok := summaries[channel]; !ok {
+				summaries[channel] = summary.New(log, summary.NewDynamoDB(client, cfg.SummaryTable, channel), api, channel, cfg.SeverityThresholds)
+			}
+			return summaries[channel]
+		}
+		a.summary = newSummary(a.log, a.slack, cmp.Or(cfg.SummaryChannel, cfg.SlackChannel))
+		for _, d := range a.destinations {
+			d.summary = newSummary(a.log.With("destination", d.Name), d.client, d.Channel)
+		}
+	}
+	return a, nil
+}
+
+func (a *App) Config() Config {
+	return a.cfg
+}
+
+func (a *App) Logger() *slog.Logger {
+	return a.log
+}
+
+// StatusObserver returns what should hear about acknowledged and resolved
+// findings, or nil.
+func (a *App) StatusObserver() ack.Observer {
+	if a.summary == nil {
+		return nil
+	}
+	return summaryObserver(a.summaries())
+}
+
+// summaryObserver tells every pinned summary about status changes; those not
+// listing the finding ignore it.
+type summaryObserver []*summary.Summary
+
+func (o summaryObserver) StatusChanged(ctx context.Context, rec ack.Record) error {
+	var errs []error
+	for _, s := range o {
+		errs = append(errs, s.StatusChanged(ctx, rec))
+	}
+	return errors.Join(errs...)
+}
+
+// Check validates the config, then verifies the slack token and that the bot
+// is a member of every configured channel. all problems are returned, joined.
+// the slack checks are skipped in dry run and when a custom poster is used.
+func (a *App) Check(ctx context.Context) error {
+	errs := []error{a.cfg.Validate()}
+	for i, ch := range a.routes.Channels() {
+		if !config.ValidChannelID(ch) {
+			errs = append(errs, fmt.Errorf("route %d: %q is not a channel id", i, ch))
+		}
+	}
+	if a.slack != nil && !a.cfg.DryRun && a.cfg.SlackToken != "" {
+		errs = append(errs, slackout.Check(ctx, a.log, a.slack, a.channels()))
+	}
+	if !a.cfg.DryRun {
+		for _, d := range a.destinations {
+			// default-token destinations are among a.channels().
+			if d.client == nil || d.client == a.slack {
+				continue
+			}
+			if err := slackout.Check(ctx, a.log.With("destination", d.Name), d.client, []string{d.Channel}); err != nil {
+				errs = append(errs, fmt.Errorf("destination %s: %w", d.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// destination is a workspace destination with what posts to it. client is
+// the default workspace's for destinations without a token of their own, and
+// nil with a custom poster.
+type destination struct {
+	routing.Destination
+	poster  SlackPoster
+	client  *slack.Client
+	summary *summary.Summary
+}
+
+// destinationFor returns the destination of f, or nil for the default
+// workspace. it matches as the notifier does.
+func (a *App) destinationFor(f Finding) *destination {
+	for _, d := range a.destinations {
+		if d.Matches(f) {
+			return d
+		}
+	}
+	return nil
+}
+
+// floodFor returns the flood control of the channel f is posted to, or nil
+// when flood control is off.
+func (a *App) floodFor(f Finding) *throttle.Flood {
+	if a.floods == nil {
+		return nil
+	}
+	if d := a.destinationFor(f); d != nil {
+		return a.floods.For(a.log.With("destination", d.Name), d.client, d.Channel)
+	}
+	channel := a.cfg.SlackChannel
+	// routes and account channels are the notifier's to pick.
+	if n, ok := a.notifier.(interface{ ChannelFor(Finding) string }); ok {
+		channel = n.ChannelFor(f)
+	}
+	return a.floods.For(a.log, a.slack, channel)
+}
+
+// summaries lists the distinct pinned summaries of the default workspace and
+// the destinations.
+func (a *App) summaries() []*summary.Summary {
+	if a.summary == nil {
+		return nil
+	}
+	out := []*summary.Summary{a.summary}
+	for _, d := range a.destinations {
+		if !slices.Contains(out, d.summary) {
+			out = append(out, d.summary)
+		}
+	}
+	return out
+}
+
+// channels lists every distinct, well-formed channel of the default workspace
+// a finding can go to.
+func (a *App) channels() []string {
+	all := append([]string{a.cfg.SlackChannel, a.cfg.SummaryChannel}, a.routes.Channels()...)
+	for _, account := range slices.Sorted(maps.Keys(a.cfg.AccountChannels)) {
+		all = append(all, a.cfg.AccountChannels[account])
+	}
+	for _, d := range a.dests {
+		if d.Token == "" || d.Token == a.cfg.SlackToken {
+			all = append(all, d.Channel)
+		}
+	}
+	var out []string
+	for _, ch := range all {
+		if config.ValidChannelID(ch) && !slices.Contains(out, ch) {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// HandleEvent dispatches a single eventbridge event: scheduled events post the
+// digest, everything else is processed as a finding.
+func (a *App) HandleEvent(ctx context.Context, evt events.CloudWatchEvent) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HandleEvent",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(tracing.EventAttributes(evt)...),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	a.log.Info("event received",
+		"event_id", evt.ID,
+		"source", evt.Source,
+		"detail_type", evt.DetailType,
+	)
+	if isScheduledEvent(evt) {
+		// leave the buffer for the first schedule after quiet hours.
+		if a.cfg.QuietHours.Contains(time.Now()) {
+			a.log.Info("digest held for quiet hours", "quiet_hours", a.cfg.QuietHours.String())
+		} else {
+			err = a.PostDigest(ctx)
+		}
+		for _, s := range a.summaries() {
+			if serr := s.Refresh(ctx); serr != nil {
+				a.log.Error("failed to refresh summary", "error", serr)
+			}
+		}
+		return err
+	}
+
+	if a.log.Enabled(ctx, slog.LevelDebug) {
+		evtJson, err := json.Marshal(evt)
+		if err != nil {
+			a.log.Error("failed to marshal event", "error", err)
+		}
+		a.log.Debug("event payload", "event", json.RawMessage(evtJson))
+	}
+
+	return a.process(ctx, evt)
+}
+
+// HandleEvents processes evts with at most cfg.MaxConcurrency in flight and
+// returns one error per event, nil for those that succeeded. a failing event
+// never stops the others.
+func (a *App) HandleEvents(ctx context.Context, evts []events.CloudWatchEvent) []error {
+	errs := make([]error, len(evts))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(1, a.cfg.MaxConcurrency), len(evts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = a.HandleEvent(ctx, evts[i])
+			}
+		}()
+	}
+	for i := range evts {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return errs
+}
+
+func isScheduledEvent(evt events.CloudWatchEvent) bool {
+	return evt.Source == "aws.events" && evt.DetailType == "Scheduled Event"
+}
+
+// Process parses, enriches and delivers a single guardduty finding detail.
+// use HandleEvent for inspector2 and health events.
+func (a *App) Process(ctx context.Context, raw json.RawMessage) error {
+	return a.process(ctx, events.CloudWatchEvent{Detail: raw})
+}
+
+func (a *App) process(ctx context.Context, evt events.CloudWatchEvent) error {
+	start := time.Now()
+
+	_, span := tracing.Tracer().Start(ctx, "parse")
+	f, err := finding.ParseEvent(evt.DetailType, evt.Detail, a.cfg.AwsConsoleURL)
+	tracing.End(span, err)
+	if err != nil {
+		a.log.Error("failed to parse finding", "event_id", evt.ID, "detail_type", evt.DetailType, "error", err)
+		a.metrics.Put(nil, metrics.Count(metrics.ParseFailures))
+		// the archive keeps what could not be parsed too.
+		unparsed := Finding{AccountID: evt.AccountID, Region: evt.Region, SeverityLabel: finding.SeverityUnknown}
+		a.archiveEvent(ctx, a.log.With("event_id", evt.ID), evt, unparsed, nil)
+		return a.parkEvent(ctx, evt, err)
+	}
+	f.SeverityLabel, f.SeverityName = a.cfg.SeverityThresholds.Classify(f.Severity)
+	// health events for the function's own account omit it from the detail.
+	if f.AccountID == "" {
+		f.AccountID = evt.AccountID
+	}
+	if f.Region == "" {
+		f.Region = evt.Region
+	}
+	dims := metrics.FindingDimensions(f)
+	trace.SpanFromContext(ctx).SetAttributes(tracing.FindingAttributes(f)...)
+
+	log := a.log.With(
+		"source", f.Source,
+		"finding_id", f.ID,
+		"account_id", f.AccountID,
+		"severity", f.Severity,
+		"severity_label", f.SeverityLabel,
+	)
+	log.Debug("finding payload", "finding", f.Raw)
+
+	a.archiveEvent(ctx, log, evt, f, dims)
+
+	key := dedupe.Key(f)
+	if a.filter(ctx, log, f, key, dims) != "" {
+		return nil
+	}
+
+	enrichCtx, span := tracing.Tracer().Start(ctx, "enrich")
+	a.resolveAccount(enrichCtx, &f)
+	// closing a finding needs no message; opening one waits for its post.
+	if f.Service.Archived {
+		a.trackSummary(enrichCtx, log, f)
+	}
+	span.End()
+
+	// quiet hours and digests hold back the slack post, never the page.
+	a.escalateFinding(ctx, log, f, dims)
+
+	if quiet := a.quiet(f); quiet || a.shouldDigest(f) {
+		log = log.With("quiet_hours", quiet)
+		if a.cfg.DryRun {
+			log.Info("dry run: finding would be buffered for digest", "type", f.Type)
+			return nil
+		}
+		digestCtx, span := tracing.Tracer().Start(ctx, "digest")
+		entry := digest.NewEntry(f)
+		if d := a.destinationFor(f); d != nil {
+			entry.Destination = d.Name
+		}
+		err := a.digest.Add(digestCtx, entry)
+		tracing.End(span, err)
+		if err != nil {
+			log.Error("failed to buffer finding for digest", "error", err)
+			a.release(ctx, log, key)
+			return err
+		}
+		log.Info("finding buffered for digest", "type", f.Type)
+		a.metrics.Put(dims, metrics.Count(metrics.FindingsDigested))
+		return nil
+	}
+
+	if flood := a.floodFor(f); flood != nil {
+		admitted, err := flood.Admit(ctx, f)
+		if err != nil {
+			log.Warn("flood control failed, posting anyway", "error", err)
+		} else if !admitted {
+			log.Info("finding collapsed into storm message", "type", f.Type)
+			a.metrics.Put(dims, metrics.Count(metrics.FindingsThrottled))
+			return nil
+		}
+	}
+
+	postCtx, span := tracing.Tracer().Start(ctx, "post")
+	err = a.notifier.Notify(postCtx, f)
+	// time from publication to delivery, across the whole fleet's events.
+	if !evt.Time.IsZero() {
+		span.SetAttributes(attribute.Int64("delivery.delay_ms", time.Since(evt.Time).Milliseconds()))
+	}
+	tracing.End(span, err)
+	if err != nil {
+		log.Error("failed to post finding", "error", err)
+		a.metrics.Put(dims, metrics.Count(metrics.SlackFailures))
+		a.release(ctx, log, key)
+		return err
+	}
+	log.Info("finding posted", "type", f.Type)
+	if !f.Service.Archived {
+		a.trackSummary(ctx, log, f)
+	}
+	a.metrics.Put(dims,
+		metrics.Count(metrics.FindingsProcessed),
+		metrics.Latency(metrics.ProcessingLatency, time.Since(start)),
+	)
+	return nil
+}
+
+// parkEvent hands an event that cannot be parsed to the parker so it is not
+// retried forever. cause is returned when parking is disabled or fails.
+func (a *App) parkEvent(ctx context.Context, evt events.CloudWatchEvent, cause error) error {
+	if a.park == nil {
+		return cause
+	}
+	if err := a.park.Park(ctx, evt, cause); err != nil {
+		a.log.Error("failed to park unparseable event", "event_id", evt.ID, "error", err)
+		return errors.Join(cause, err)
+	}
+	a.log.Warn("unparseable event parked", "event_id", evt.ID, "detail_type", evt.DetailType)
+	a.metrics.Put(nil, metrics.Count(metrics.EventsParked))
+	return nil
+}
+
+// filter drops findings that need no post: suppressed ones and duplicates
+// already claimed by an earlier delivery. it returns why, or an empty string
+// when the finding should go on.
+func (a *App) filter(ctx context.Context, log *slog.Logger, f Finding, key string, dims map[string]string) (outcome string) {
+	ctx, span := tracing.Tracer().Start(ctx, "filter")
+	defer func() {
+		span.SetAttributes(attribute.String("filter.outcome", cmp.Or(outcome, "pass")))
+		span.End()
+	}()
+
+	if reason := a.suppressReason(f); reason != "" {
+		if f.Service.Archived {
+			a.trackSummary(ctx, log, f)
+		}
+		log.Info("finding suppressed", "reason", reason, "count", f.Service.Count)
+		a.metrics.Put(dims, metrics.Count(metrics.FindingsSuppressed))
+		return reason
+	}
+
+	if claimed, err := a.dedupe.Claim(ctx, key); err != nil {
+		// posting twice beats dropping a finding when the store is unavailable.
+		log.Warn("idempotency check failed, processing anyway", "error", err)
+	} else if !claimed {
+		log.Info("duplicate finding skipped", "updated_at", f.UpdatedAt)
+		a.metrics.Put(dims, metrics.Count(metrics.FindingsDuplicate))
+		return "duplicate"
+	}
+	return ""
+}
+
+// suppressReason explains why a finding is intentionally not delivered, or
+// returns an empty string.
+func (a *App) suppressReason(f Finding) string {
+	switch {
+	case f.Service.Archived && a.cfg.SkipArchived:
+		return "archived"
+	case f.Reobserved() && a.cfg.SkipReobserved:
+		return "reobserved"
+	default:
+		return ""
+	}
+}
+
+// escalateFinding pages for findings at or above the escalation threshold. a
+// failed page is logged and counted but does not block the slack post;
+// alarm on EscalationFailures.
+func (a *App) escalateFinding(ctx context.Context, log *slog.Logger, f Finding, dims map[string]string) {
+	if a.escalate == nil {
+		return
+	}
+	if !a.cfg.SeverityThresholds.AtLeast(f.Severity, string(a.cfg.EscalationMinSeverity)) {
+		return
+	}
+	if a.cfg.DryRun {
+		log.Info("dry run: finding would be escalated", "backend", a.cfg.EscalationBackend)
+		return
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "escalate",
+		trace.WithAttributes(attribute.String("escalation.backend", a.cfg.EscalationBackend)),
+	)
+	err := a.escalate.Escalate(ctx, f)
+	tracing.End(span, err)
+	if err != nil {
+		log.Error("failed to escalate finding", "backend", a.cfg.EscalationBackend, "error", err)
+		a.metrics.Put(dims, metrics.Count(metrics.EscalationFailures))
+		return
+	}
+	log.Info("finding escalated", "backend", a.cfg.EscalationBackend)
+	a.metrics.Put(dims, metrics.Count(metrics.FindingsEscalated))
+}
+
+// archiveEvent writes the raw event to the audit trail before anything can
+// drop it. an s3 outage must not stop alerting, so failures are only logged
+// and counted; alarm on ArchiveFailures.
+func (a *App) archiveEvent(ctx context.Context, log *slog.Logger, evt events.CloudWatchEvent, f Finding, dims map[string]string) {
+	if a.archive == nil {
+		return
+	}
+	if a.cfg.DryRun {
+		log.Info("dry run: event would be archived", "bucket", a.cfg.ArchiveBucket)
+		return
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "archive")
+	err := a.archive.Archive(ctx, evt, f)
+	tracing.End(span, err)
+	if err != nil {
+		log.Error("failed to archive event", "error", err)
+		a.metrics.Put(dims, metrics.Count(metrics.ArchiveFailures))
+	}
+}
+
+// trackSummary updates the pinned summary. it is only a view, so failures
+// are logged and never block delivery.
+func (a *App) trackSummary(ctx context.Context, log *slog.Logger, f Finding) {
+	s := a.summary
+	if d := a.destinationFor(f); d != nil {
+		s = d.summary
+	}
+	if s == nil {
+		return
+	}
+	if err := s.Track(ctx, f); err != nil {
+		log.Warn("failed to update summary", "error", err)
+	}
+}
+
+// release drops the idempotency claim so the retry of a failed delivery is
+// not mistaken for a duplicate.
+func (a *App) release(ctx context.Context, log *slog.Logger, key string) {
+	if err := a.dedupe.Release(ctx, key); err != nil {
+		log.Warn("failed to release idempotency key", "error", err)
+	}
+}
+
+func (a *App) resolveAccount(ctx context.Context, f *Finding) {
+	if a.accounts != nil {
+		name, err := a.accounts.AccountName(ctx, f.AccountID)
+		if err != nil {
+			a.log.Warn("failed to resolve account name", "account_id", f.AccountID, "error", err)
+		}
+		f.AccountName = name
+	}
+
+	if a.context != nil {
+		// partial context is still useful, so fill in what was found.
+		info, err := a.context.Describe(ctx, f.AccountID)
+		if err != nil {
+			a.log.Warn("failed to describe account", "account_id", f.AccountID, "error", err)
+		}
+		f.AccountEmail, f.AccountOU, f.AccountTeam = info.Email, info.OU, info.Team
+		f.AccountTags = info.Tags
+	}
+}
+
+// ------------------------------------------------------------------ digest ---
+
+// shouldDigest matches labels and levels case-insensitively, as config
+// validation does.
+func (a *App) shouldDigest(f Finding) bool {
+	return a.digest != nil && slices.ContainsFunc(a.cfg.DigestSeverities, func(s finding.SeverityLevel) bool {
+		return strings.EqualFold(string(s), string(f.SeverityLabel)) || strings.EqualFold(string(s), f.SeverityName)
+	})
+}
+
+// quiet reports whether f is held for the digest because it arrived during
+// quiet hours below the severity that still posts immediately.
+func (a *App) quiet(f Finding) bool {
+	if a.digest == nil || !a.cfg.QuietHours.Contains(time.Now()) {
+		return false
+	}
+	return !a.cfg.SeverityThresholds.AtLeast(f.Severity, string(a.cfg.QuietHoursMinSeverity))
+}
+
+// PostDigest drains the digest buffer and posts one summary message. entries
+// are only removed from the buffer after slack accepted the message.
+func (a *App) PostDigest(ctx context.Context) error {
+	if a.digest == nil {
+		a.log.Warn("digest invocation received but APP_DIGEST_QUEUE_URL is not set")
+		return nil
+	}
+	// receiving hides the entries from the live function for the visibility
+	// timeout, so a shadow deployment leaves the queue alone.
+	if a.cfg.DryRun {
+		a.log.Info("dry run: digest queue not read")
+		return nil
+	}
+
+	entries, err := a.digest.Receive(ctx, digest.MaxEntries)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		a.log.Info("digest empty, nothing to post")
+		return nil
+	}
+
+	// each destination gets a digest of its own findings.
+	groups := map[string][]digest.Entry{}
+	for _, e := range entries {
+		groups[e.Destination] = append(groups[e.Destination], e)
+	}
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		errs = append(errs, a.postDigest(ctx, name, groups[name]))
+	}
+	return errors.Join(errs...)
+}
+
+// postDigest posts the entries of the named destination, or of the default
+// workspace when name is empty or no longer configured, and removes them
+// once slack accepted the message.
+func (a *App) postDigest(ctx context.Context, name string, entries []digest.Entry) error {
+	log, poster, channel := a.log, a.poster, a.cfg.SlackChannel
+	if i := slices.IndexFunc(a.destinations, func(d *destination) bool { return d.Name == name }); i >= 0 {
+		d := a.destinations[i]
+		log, poster, channel = a.log.With("destination", name), d.poster, d.Channel
+	}
+
+	_, err := poster.PostMessage(ctx,
+		channel,
+		"",
+		fmt.Sprintf("GuardDuty digest: %d findings", len(entries)),
+		digest.Render(entries),
+	)
+	if err != nil {
+		a.metrics.Put(nil, metrics.Count(metrics.SlackFailures))
+		return fmt.Errorf("post digest: %w", err)
+	}
+	log.Info("digest posted", "findings", len(entries))
+	return a.digest.Remove(ctx, entries)
+}
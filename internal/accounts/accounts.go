@@ -0,0 +1,92 @@
+// accounts.go
+//
+// account names — resolve 12-digit account ids to friendly names from a
+// static APP_ACCOUNT_MAP and/or aws organizations.
+
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+)
+
+type Resolver interface {
+	// AccountName returns the friendly name for id, or an empty string when
+	// the account is unknown.
+	AccountName(ctx context.Context, id string) (string, error)
+}
+
+// ---------------------------------------------------------------- static ---
+
+type Static map[string]string
+
+func (r Static) AccountName(_ context.Context, id string) (string, error) {
+	return r[id], nil
+}
+
+// --------------------------------------------------------- organizations ---
+
+type Organizations struct {
+	client *organizations.Client
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	names     map[string]string
+	fetchedAt time.Time
+}
+
+func NewOrganizations(client *organizations.Client, ttl time.Duration) *Organizations {
+	return &Organizations{client: client, ttl: ttl}
+}
+
+func (r *Organizations) AccountName(ctx context.Context, id string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names == nil || time.Since(r.fetchedAt) > r.ttl {
+		names, err := r.listAccounts(ctx)
+		if err != nil {
+			return "", err
+		}
+		r.names, r.fetchedAt = names, time.Now()
+	}
+	return r.names[id], nil
+}
+
+func (r *Organizations) listAccounts(ctx context.Context) (map[string]string, error) {
+	names := map[string]string{}
+	p := organizations.NewListAccountsPaginator(r.client, &organizations.ListAccountsInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list organization accounts: %w", err)
+		}
+		for _, acct := range page.Accounts {
+			names[aws.ToString(acct.Id)] = aws.ToString(acct.Name)
+		}
+	}
+	return names, nil
+}
+
+// ----------------------------------------------------------------- chain ---
+
+// Chain returns the first non-empty name from its resolvers.
+type Chain []Resolver
+
+func (c Chain) AccountName(ctx context.Context, id string) (string, error) {
+	for _, r := range c {
+		name, err := r.AccountName(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if name != "" {
+			return name, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,220 @@
+// context.go
+//
+// member account context — in a guardduty delegated administrator account
+// findings arrive for member accounts. look up who owns them: contact email,
+// organizational unit and an optional team tag.
+
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+)
+
+type Info struct {
+	Email string
+	OU    string
+	Team  string
+	// Tags are only listed when something selects on them.
+	Tags map[string]string
+}
+
+func (i Info) merge(o Info) Info {
+	if i.Email == "" {
+		i.Email = o.Email
+	}
+	if i.OU == "" {
+		i.OU = o.OU
+	}
+	if i.Team == "" {
+		i.Team = o.Team
+	}
+	if i.Tags == nil {
+		i.Tags = o.Tags
+	}
+	return i
+}
+
+type Describer interface {
+	// Describe returns what is known about the account; unknown fields are
+	// left empty.
+	Describe(ctx context.Context, id string) (Info, error)
+}
+
+// infoCache memoizes lookups per account for ttl.
+type infoCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedInfo
+}
+
+type cachedInfo struct {
+	info      Info
+	fetchedAt time.Time
+}
+
+func (c *infoCache) get(ctx context.Context, id string, fetch func(context.Context, string) (Info, error)) (Info, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[id]; ok && time.Since(e.fetchedAt) <= c.ttl {
+		return e.info, nil
+	}
+	info, err := fetch(ctx, id)
+	if err != nil {
+		return Info{}, err
+	}
+	if c.entries == nil {
+		c.entries = map[string]cachedInfo{}
+	}
+	c.entries[id] = cachedInfo{info: info, fetchedAt: time.Now()}
+	return info, nil
+}
+
+// ------------------------------------------------- organizations context ---
+
+// OrganizationsContext reads the account email, parent ou and, when teamTag
+// is set, the value of that tag on the account. with allTags every tag is
+// kept in Info.Tags.
+type OrganizationsContext struct {
+	client  *organizations.Client
+	teamTag string
+	allTags bool
+	cache   infoCache
+}
+
+func NewOrganizationsContext(client *organizations.Client, teamTag string, allTags bool, ttl time.Duration) *OrganizationsContext {
+	return &OrganizationsContext{client: client, teamTag: teamTag, allTags: allTags, cache: infoCache{ttl: ttl}}
+}
+
+func (d *OrganizationsContext) Describe(ctx context.Context, id string) (Info, error) {
+	return d.cache.get(ctx, id, d.fetch)
+}
+
+func (d *OrganizationsContext) fetch(ctx context.Context, id string) (Info, error) {
+	var info Info
+
+	acct, err := d.client.DescribeAccount(ctx, &organizations.DescribeAccountInput{AccountId: aws.String(id)})
+	if err != nil {
+		var notFound *orgtypes.AccountNotFoundException
+		if errors.As(err, &notFound) {
+			return Info{}, nil
+		}
+		return Info{}, fmt.Errorf("describe account: %w", err)
+	}
+	info.Email = aws.ToString(acct.Account.Email)
+
+	parents, err := d.client.ListParents(ctx, &organizations.ListParentsInput{ChildId: aws.String(id)})
+	if err != nil {
+		return Info{}, fmt.Errorf("list account parents: %w", err)
+	}
+	if len(parents.Parents) > 0 && parents.Parents[0].Type == orgtypes.ParentTypeOrganizationalUnit {
+		ou, err := d.client.DescribeOrganizationalUnit(ctx, &organizations.DescribeOrganizationalUnitInput{
+			OrganizationalUnitId: parents.Parents[0].Id,
+		})
+		if err != nil {
+			return Info{}, fmt.Errorf("describe organizational unit: %w", err)
+		}
+		info.OU = aws.ToString(ou.OrganizationalUnit.Name)
+	}
+
+	if d.teamTag != "" || d.allTags {
+		if d.allTags {
+			info.Tags = map[string]string{}
+		}
+		p := organizations.NewListTagsForResourcePaginator(d.client, &organizations.ListTagsForResourceInput{
+			ResourceId: aws.String(id),
+		})
+		for p.HasMorePages() && (info.Team == "" || d.allTags) {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return Info{}, fmt.Errorf("list account tags: %w", err)
+			}
+			for _, tag := range page.Tags {
+				key, value := aws.ToString(tag.Key), aws.ToString(tag.Value)
+				if key == d.teamTag {
+					info.Team = value
+				}
+				if d.allTags {
+					info.Tags[key] = value
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// ----------------------------------------------------- guardduty members ---
+
+// GuardDutyMembers reads the member email the account was invited with. it
+// only needs guardduty permissions, so it works in a guardduty delegated
+// administrator that is not the organizations management account.
+type GuardDutyMembers struct {
+	client     *guardduty.Client
+	detectorID string
+	cache      infoCache
+}
+
+// NewGuardDutyMembers builds the lookup. an empty detectorID uses the first
+// detector in the region.
+func NewGuardDutyMembers(client *guardduty.Client, detectorID string, ttl time.Duration) *GuardDutyMembers {
+	return &GuardDutyMembers{client: client, detectorID: detectorID, cache: infoCache{ttl: ttl}}
+}
+
+func (d *GuardDutyMembers) Describe(ctx context.Context, id string) (Info, error) {
+	return d.cache.get(ctx, id, d.fetch)
+}
+
+// fetch runs under the cache lock, so resolving the detector is not racy.
+func (d *GuardDutyMembers) fetch(ctx context.Context, id string) (Info, error) {
+	if d.detectorID == "" {
+		out, err := d.client.ListDetectors(ctx, &guardduty.ListDetectorsInput{})
+		if err != nil {
+			return Info{}, fmt.Errorf("list detectors: %w", err)
+		}
+		if len(out.DetectorIds) == 0 {
+			return Info{}, errors.New("no guardduty detector found; set APP_GUARDDUTY_DETECTOR_ID")
+		}
+		d.detectorID = out.DetectorIds[0]
+	}
+	out, err := d.client.GetMembers(ctx, &guardduty.GetMembersInput{
+		DetectorId: aws.String(d.detectorID),
+		AccountIds: []string{id},
+	})
+	if err != nil {
+		return Info{}, fmt.Errorf("get guardduty members: %w", err)
+	}
+	if len(out.Members) == 0 {
+		return Info{}, nil
+	}
+	return Info{Email: aws.ToString(out.Members[0].Email)}, nil
+}
+
+// --------------------------------------------------------- context chain ---
+
+// DescriberChain merges what every describer knows, earlier ones winning. a
+// failing describer does not hide the others' results.
+type DescriberChain []Describer
+
+func (c DescriberChain) Describe(ctx context.Context, id string) (Info, error) {
+	var info Info
+	var errs []error
+	for _, d := range c {
+		i, err := d.Describe(ctx, id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		info = info.merge(i)
+	}
+	return info, errors.Join(errs...)
+}
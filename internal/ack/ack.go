@@ -0,0 +1,351 @@
+// ack.go
+//
+// triage status — "Acknowledge" and "Resolve" buttons on finding messages.
+// clicks are recorded in dynamodb, shown on the original message and can be
+// sent to guardduty as finding feedback.
+
+package ack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	gdtypes "github.com/aws/aws-sdk-go-v2/service/guardduty/types"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+// button action ids, as used by the message template.
+const (
+	ActionAcknowledge = "ack"
+	ActionResolve     = "resolve"
+)
+
+// statusBlockID marks the context block that lists status changes.
+const statusBlockID = "ack-status"
+
+type Status string
+
+const (
+	StatusAcknowledged Status = "acknowledged"
+	StatusResolved     Status = "resolved"
+)
+
+// StatusFor maps a button action id to the status it sets.
+func StatusFor(actionID string) (Status, bool) {
+	switch actionID {
+	case ActionAcknowledge:
+		return StatusAcknowledged, true
+	case ActionResolve:
+		return StatusResolved, true
+	default:
+		return "", false
+	}
+}
+
+// Ref identifies the finding a button belongs to. it travels in the button
+// value, so it is kept short.
+type Ref struct {
+	Source    string `json:"s"`
+	ID        string `json:"id"`
+	AccountID string `json:"a"`
+	Region    string `json:"r"`
+}
+
+func NewRef(f finding.Finding) Ref {
+	return Ref{
+		Source:    f.Source,
+		ID:        f.ID,
+		AccountID: f.AccountID,
+		Region:    f.Region,
+	}
+}
+
+// Value encodes the ref as a button value.
+func (r Ref) Value() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+func ParseRef(value string) (Ref, error) {
+	var r Ref
+	if err := json.Unmarshal([]byte(value), &r); err != nil {
+		return Ref{}, fmt.Errorf("parse button value: %w", err)
+	}
+	if r.ID == "" {
+		return Ref{}, fmt.Errorf("parse button value: missing finding id")
+	}
+	return r, nil
+}
+
+// Key is the status record's partition key.
+func (r Ref) Key() string {
+	return r.Source + "/" + r.AccountID + "/" + r.ID
+}
+
+// Record is one status change.
+type Record struct {
+	Ref
+	Status   Status
+	UserID   string
+	UserName string
+	At       time.Time
+	Channel  string
+	TS       string
+}
+
+// ------------------------------------------------------------------ store ---
+
+type Store interface {
+	Put(ctx context.Context, rec Record) error
+}
+
+// DynamoDB keeps one item per finding. the table needs a string partition key
+// named "id". each status keeps its own who/when attributes, so resolving
+// does not erase who acknowledged.
+type DynamoDB struct {
+	client *dynamodb.Client
+	table  string
+}
+
+func NewDynamoDB(client *dynamodb.Client, table string) *DynamoDB {
+	return &DynamoDB{client: client, table: table}
+}
+
+func (d *DynamoDB) Put(ctx context.Context, rec Record) error {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.table),
+		Key: map[string]ddbtypes.AttributeValue{
+			"id": &ddbtypes.AttributeValueMemberS{Value: rec.Key()},
+		},
+		UpdateExpression: aws.String(fmt.Sprintf(
+			"SET #status = :status, findingId = :finding, accountId = :account, #region = :region, "+
+				"#channel = :channel, messageTs = :ts, %[1]sBy = :user, %[1]sByName = :name, %[1]sAt = :at",
+			rec.Status,
+		)),
+		ExpressionAttributeNames: map[string]string{
+			"#status":  "status",
+			"#region":  "region",
+			"#channel": "channel",
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":status":  &ddbtypes.AttributeValueMemberS{Value: string(rec.Status)},
+			":finding": &ddbtypes.AttributeValueMemberS{Value: rec.ID},
+			":account": &ddbtypes.AttributeValueMemberS{Value: rec.AccountID},
+			":region":  &ddbtypes.AttributeValueMemberS{Value: rec.Region},
+			":channel": &ddbtypes.AttributeValueMemberS{Value: rec.Channel},
+			":ts":      &ddbtypes.AttributeValueMemberS{Value: rec.TS},
+			":user":    &ddbtypes.AttributeValueMemberS{Value: rec.UserID},
+			":name":    &ddbtypes.AttributeValueMemberS{Value: rec.UserName},
+			":at":      &ddbtypes.AttributeValueMemberS{Value: rec.At.UTC().Format(time.RFC3339)},
+		},
+	})
+	return err
+}
+
+// ------------------------------------------------------------------ acker ---
+
+type FeedbackAPI interface {
+	ListDetectors(ctx context.Context, in *guardduty.ListDetectorsInput, opts ...func(*guardduty.Options)) (*guardduty.ListDetectorsOutput, error)
+	UpdateFindingsFeedback(ctx context.Context, in *guardduty.UpdateFindingsFeedbackInput, opts ...func(*guardduty.Options)) (*guardduty.UpdateFindingsFeedbackOutput, error)
+}
+
+// Observer is told about status changes after they are recorded.
+type Observer interface {
+	StatusChanged(ctx context.Context, rec Record) error
+}
+
+type Acker struct {
+	log        *slog.Logger
+	store      Store
+	feedback   FeedbackAPI
+	observer   Observer
+	detectorID string
+	httpClient *http.Client
+
+	mu sync.Mutex
+	// detectors caches this account's detector id per region.
+	detectors map[string]string
+}
+
+// NewAcker handles button clicks. store, feedback and observer are optional;
+// detectorID is used for findings whose button value carries no region.
+// replies go through httpClient so they honor the slack egress settings.
+func NewAcker(log *slog.Logger, store Store, feedback FeedbackAPI, observer Observer, detectorID string, httpClient *http.Client) *Acker {
+	return &Acker{
+		log: log, store: store, feedback: feedback, observer: observer, detectorID: detectorID, httpClient: httpClient,
+		detectors: map[string]string{},
+	}
+}
+
+// Handle processes a block_actions interaction. clicks on other buttons are
+// ignored. the status is recorded before the message is edited, so the
+// message never shows a status that was not saved.
+func (a *Acker) Handle(ctx context.Context, cb slack.InteractionCallback) error {
+	for _, action := range cb.ActionCallback.BlockActions {
+		status, ok := StatusFor(action.ActionID)
+		if !ok {
+			continue
+		}
+		ref, err := ParseRef(action.Value)
+		if err != nil {
+			return err
+		}
+		rec := Record{
+			Ref:      ref,
+			Status:   status,
+			UserID:   cb.User.ID,
+			UserName: cb.User.Name,
+			At:       time.Now(),
+			Channel:  cb.Channel.ID,
+			TS:       cb.Container.MessageTs,
+		}
+		log := a.log.With("finding_id", ref.ID, "account_id", ref.AccountID, "status", status, "user_id", rec.UserID)
+
+		if a.store != nil {
+			if err := a.store.Put(ctx, rec); err != nil {
+				return fmt.Errorf("record status: %w", err)
+			}
+		}
+		if err := a.sendFeedback(ctx, rec); err != nil {
+			// the triage state is saved; feedback is best effort.
+			log.Warn("failed to send guardduty feedback", "error", err)
+		}
+		if err := a.updateMessage(ctx, cb, rec); err != nil {
+			return fmt.Errorf("update message: %w", err)
+		}
+		if a.observer != nil {
+			if err := a.observer.StatusChanged(ctx, rec); err != nil {
+				log.Warn("failed to notify status observer", "error", err)
+			}
+		}
+		log.Info("finding status updated")
+	}
+	return nil
+}
+
+// sendFeedback marks resolved guardduty findings as useful. it goes to this
+// account's detector in the finding's region, which for a member's finding is
+// the administrator's detector, not the member's own.
+func (a *Acker) sendFeedback(ctx context.Context, rec Record) error {
+	if a.feedback == nil || rec.Status != StatusResolved || rec.Source != finding.SourceGuardDuty {
+		return nil
+	}
+	detectorID, err := a.detectorFor(ctx, rec.Region)
+	if err != nil {
+		return err
+	}
+	_, err = a.feedback.UpdateFindingsFeedback(ctx, &guardduty.UpdateFindingsFeedbackInput{
+		DetectorId: aws.String(detectorID),
+		FindingIds: []string{rec.ID},
+		Feedback:   gdtypes.FeedbackUseful,
+		Comments:   aws.String(fmt.Sprintf("resolved in slack by %s", rec.UserName)),
+	}, inRegion(rec.Region))
+	return err
+}
+
+// detectorFor returns this account's detector in region, looked up once per
+// region. findings without a region use the configured detector.
+func (a *Acker) detectorFor(ctx context.Context, region string) (string, error) {
+	if region == "" {
+		if a.detectorID == "" {
+			return "", fmt.Errorf("no region or detector id for finding; set APP_GUARDDUTY_DETECTOR_ID")
+		}
+		return a.detectorID, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if id, ok := a.detectors[region]; ok {
+		return id, nil
+	}
+	out, err := a.feedback.ListDetectors(ctx, &guardduty.ListDetectorsInput{}, inRegion(region))
+	if err != nil {
+		return "", fmt.Errorf("list detectors in %s: %w", region, err)
+	}
+	if len(out.DetectorIds) == 0 {
+		return "", fmt.Errorf("no guardduty detector in %s", region)
+	}
+	a.detectors[region] = out.DetectorIds[0]
+	return out.DetectorIds[0], nil
+}
+
+func inRegion(region string) func(*guardduty.Options) {
+	return func(o *guardduty.Options) {
+		if region != "" {
+			o.Region = region
+		}
+	}
+}
+
+// updateMessage replaces the original message through the interaction's
+// response url, which needs no extra token scopes.
+func (a *Acker) updateMessage(ctx context.Context, cb slack.InteractionCallback, rec Record) error {
+	msg := &slack.WebhookMessage{Text: cb.Message.Text, ReplaceOriginal: true}
+	if len(cb.Message.Blocks.BlockSet) > 0 {
+		msg.Blocks = &slack.Blocks{BlockSet: ApplyStatus(cb.Message.Blocks.BlockSet, rec)}
+	} else if len(cb.Message.Attachments) > 0 {
+		// attachment style keeps the blocks inside the color bar.
+		msg.Attachments = slices.Clone(cb.Message.Attachments)
+		msg.Attachments[0].Blocks = slack.Blocks{BlockSet: ApplyStatus(msg.Attachments[0].Blocks.BlockSet, rec)}
+	}
+	return slack.PostWebhookCustomHTTPContext(ctx, cb.ResponseURL, a.httpClient, msg)
+}
+
+// ---------------------------------------------------------------- message ---
+
+// ApplyStatus adds the status change to the message's status line, creating
+// it above the buttons, and drops buttons that no longer apply: acknowledge
+// once acknowledged, both once resolved.
+func ApplyStatus(blocks []slack.Block, rec Record) []slack.Block {
+	text := slack.NewTextBlockObject(slack.MarkdownType, StatusText(rec), false, false)
+
+	out := make([]slack.Block, 0, len(blocks)+1)
+	added := false
+	for _, b := range blocks {
+		switch b := b.(type) {
+		case *slack.ContextBlock:
+			if b.BlockID == statusBlockID {
+				b.ContextElements.Elements = append(b.ContextElements.Elements, text)
+				added = true
+			}
+		case *slack.ActionBlock:
+			if b.BlockID == "actions" && !added {
+				out = append(out, slack.NewContextBlock(statusBlockID, text))
+				added = true
+			}
+			if b.Elements != nil {
+				b.Elements.ElementSet = slices.DeleteFunc(b.Elements.ElementSet, func(e slack.BlockElement) bool {
+					btn, ok := e.(*slack.ButtonBlockElement)
+					return ok && (btn.ActionID == ActionAcknowledge || btn.ActionID == ActionResolve && rec.Status == StatusResolved)
+				})
+			}
+		}
+		out = append(out, b)
+	}
+	if !added {
+		out = append(out, slack.NewContextBlock(statusBlockID, text))
+	}
+	return out
+}
+
+// StatusText renders e.g. ":eyes: Acknowledged by <@U123> <!date^…>".
+func StatusText(rec Record) string {
+	icon, verb := ":eyes:", "Acknowledged"
+	if rec.Status == StatusResolved {
+		icon, verb = ":white_check_mark:", "Resolved"
+	}
+	fallback := rec.At.UTC().Format("2006-01-02 15:04 UTC")
+	return fmt.Sprintf("%s %s by <@%s> <!date^%d^{date_short_pretty} {time}|%s>", icon, verb, rec.UserID, rec.At.Unix(), fallback)
+}
@@ -0,0 +1,128 @@
+package ack
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+func button(actionID string) *slack.ButtonBlockElement {
+	return slack.NewButtonBlockElement(actionID, "v", slack.NewTextBlockObject(slack.PlainTextType, actionID, false, false))
+}
+
+func TestApplyStatus(t *testing.T) {
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "title", false, false)),
+		slack.NewActionBlock("actions", button("view"), button(ActionAcknowledge), button(ActionResolve)),
+	}
+	at := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+
+	blocks = ApplyStatus(blocks, Record{Status: StatusAcknowledged, UserID: "U1", At: at})
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3", len(blocks))
+	}
+	status, ok := blocks[1].(*slack.ContextBlock)
+	if !ok || status.BlockID != statusBlockID {
+		t.Fatalf("block 1 is %T, want the status context", blocks[1])
+	}
+	if got := actionIDs(blocks[2]); len(got) != 2 || got[1] != ActionResolve {
+		t.Fatalf("buttons after ack = %v, want [view resolve]", got)
+	}
+
+	blocks = ApplyStatus(blocks, Record{Status: StatusResolved, UserID: "U2", At: at})
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3", len(blocks))
+	}
+	if n := len(blocks[1].(*slack.ContextBlock).ContextElements.Elements); n != 2 {
+		t.Fatalf("status has %d entries, want 2", n)
+	}
+	if got := actionIDs(blocks[2]); len(got) != 1 || got[0] != "view" {
+		t.Fatalf("buttons after resolve = %v, want [view]", got)
+	}
+}
+
+func TestRefValueRoundTrip(t *testing.T) {
+	ref := Ref{Source: "guardduty", ID: "abc", AccountID: "123456789012", Region: "us-east-1"}
+	got, err := ParseRef(ref.Value())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != ref {
+		t.Fatalf("got %+v, want %+v", got, ref)
+	}
+}
+
+func actionIDs(b slack.Block) []string {
+	var ids []string
+	for _, e := range b.(*slack.ActionBlock).Elements.ElementSet {
+		ids = append(ids, e.(*slack.ButtonBlockElement).ActionID)
+	}
+	return ids
+}
+
+type fakeFeedback struct {
+	// detectors by region.
+	detectors map[string]string
+	lists     int
+	// sent holds "region/detector/finding" per feedback call.
+	sent []string
+}
+
+func region(opts []func(*guardduty.Options)) string {
+	var o guardduty.Options
+	for _, fn := range opts {
+		fn(&o)
+	}
+	return o.Region
+}
+
+func (f *fakeFeedback) ListDetectors(_ context.Context, _ *guardduty.ListDetectorsInput, opts ...func(*guardduty.Options)) (*guardduty.ListDetectorsOutput, error) {
+	f.lists++
+	out := &guardduty.ListDetectorsOutput{}
+	if id, ok := f.detectors[region(opts)]; ok {
+		out.DetectorIds = []string{id}
+	}
+	return out, nil
+}
+
+func (f *fakeFeedback) UpdateFindingsFeedback(_ context.Context, in *guardduty.UpdateFindingsFeedbackInput, opts ...func(*guardduty.Options)) (*guardduty.UpdateFindingsFeedbackOutput, error) {
+	f.sent = append(f.sent, region(opts)+"/"+aws.ToString(in.DetectorId)+"/"+in.FindingIds[0])
+	return &guardduty.UpdateFindingsFeedbackOutput{}, nil
+}
+
+func TestSendFeedbackCrossRegion(t *testing.T) {
+	ctx := context.Background()
+	fb := &fakeFeedback{detectors: map[string]string{"us-east-1": "admin-use1", "eu-west-1": "admin-euw1"}}
+	a := NewAcker(slog.New(slog.DiscardHandler), nil, fb, nil, "configured", nil)
+
+	recs := []Record{
+		{Ref: Ref{Source: finding.SourceGuardDuty, ID: "f1", AccountID: "111111111111", Region: "eu-west-1"}, Status: StatusResolved},
+		{Ref: Ref{Source: finding.SourceGuardDuty, ID: "f2", AccountID: "222222222222", Region: "eu-west-1"}, Status: StatusResolved},
+		{Ref: Ref{Source: finding.SourceGuardDuty, ID: "f3", Region: "us-east-1"}, Status: StatusResolved},
+		{Ref: Ref{Source: finding.SourceGuardDuty, ID: "f4"}, Status: StatusResolved},
+	}
+	for _, rec := range recs {
+		if err := a.sendFeedback(ctx, rec); err != nil {
+			t.Fatalf("%s: %v", rec.ID, err)
+		}
+	}
+	want := []string{"eu-west-1/admin-euw1/f1", "eu-west-1/admin-euw1/f2", "us-east-1/admin-use1/f3", "/configured/f4"}
+	if !slices.Equal(fb.sent, want) {
+		t.Errorf("sent %v, want %v", fb.sent, want)
+	}
+	if fb.lists != 2 {
+		t.Errorf("listed detectors %d times, want once per region", fb.lists)
+	}
+
+	if err := a.sendFeedback(ctx, Record{Ref: Ref{Source: finding.SourceGuardDuty, ID: "f5", Region: "ap-south-1"}, Status: StatusResolved}); err == nil {
+		t.Error("a region without a detector should fail")
+	}
+}
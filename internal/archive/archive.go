@@ -0,0 +1,116 @@
+// archive.go
+//
+// event archive — every received event is written to s3 as a json line under
+// a hive-style dt/account/sev prefix, so athena can query the audit
+// trail long after cloudwatch logs expire.
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+// DefaultPrefix is used when APP_ARCHIVE_PREFIX is not set.
+const DefaultPrefix = "guardduty-findings"
+
+type Archiver interface {
+	Archive(ctx context.Context, evt events.CloudWatchEvent, f finding.Finding) error
+}
+
+// Record is the archived line: the raw event plus the fields used for
+// partitioning, so queries need not parse the detail.
+type Record struct {
+	ReceivedAt time.Time              `json:"receivedAt"`
+	Source     string                 `json:"source"`
+	FindingID  string                 `json:"findingId"`
+	Type       string                 `json:"type"`
+	AccountID  string                 `json:"accountId"`
+	Region     string                 `json:"region"`
+	Severity   float64                `json:"severity"`
+	Level      finding.SeverityLevel  `json:"severityLevel"`
+	Event      events.CloudWatchEvent `json:"event"`
+}
+
+// Key returns the object key of evt. it is derived from the event id, so a
+// redelivered event overwrites its earlier copy instead of duplicating it.
+func Key(prefix string, evt events.CloudWatchEvent, f finding.Finding) string {
+	received := evt.Time
+	if received.IsZero() {
+		received = time.Now()
+	}
+	id := evt.ID
+	if id == "" {
+		sum := sha256.Sum256(evt.Detail)
+		id = hex.EncodeToString(sum[:16])
+	}
+	return path.Join(
+		prefix,
+		"dt="+received.UTC().Format("2006-01-02"),
+		"account="+f.AccountID,
+		// not "severity", which would clash with the record field in athena.
+		"sev="+string(f.SeverityLabel),
+		id+".json",
+	)
+}
+
+// ------------------------------------------------------------------- s3 ---
+
+type S3 struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func NewS3(client *s3.Client, bucket, prefix string) *S3 {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &S3{client: client, bucket: bucket, prefix: prefix}
+}
+
+func (a *S3) Archive(ctx context.Context, evt events.CloudWatchEvent, f finding.Finding) error {
+	rec := Record{
+		ReceivedAt: evt.Time,
+		Source:     f.Source,
+		FindingID:  f.ID,
+		Type:       f.Type,
+		AccountID:  f.AccountID,
+		Region:     f.Region,
+		Severity:   f.Severity,
+		Level:      f.SeverityLabel,
+		Event:      evt,
+	}
+	if rec.ReceivedAt.IsZero() {
+		rec.ReceivedAt = time.Now().UTC()
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	key := Key(a.prefix, evt, f)
+	_, err = a.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(a.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(line),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	if err != nil {
+		return fmt.Errorf("put s3://%s/%s: %w", a.bucket, key, err)
+	}
+	return nil
+}
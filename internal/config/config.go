@@ -0,0 +1,434 @@
+// config.go
+//
+// runtime configuration loaded from APP_* environment variables. see the
+// README for the full list.
+
+package config
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/i18n"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/logging"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/throttle"
+)
+
+const (
+	SlackStyleBlocks     = "blocks"
+	SlackStyleAttachment = "attachment"
+)
+
+type Config struct {
+	DebugEnabled      bool
+	LogLevel          slog.Level
+	LogFormat         string
+	AwsConsoleURL     string
+	SlackToken        string
+	SlackChannel      string
+	SlackTemplate     string
+	SlackTemplatePath string
+	SlackStyle        string
+	SlackTimeout      time.Duration
+	SlackProxyURL     string
+	SlackCABundle     string
+	SeverityEmoji     map[finding.SeverityLevel]string
+	// SeverityThresholds map scores to labels; DefaultThresholds unless
+	// APP_SEVERITY_THRESHOLDS is set.
+	SeverityThresholds finding.Thresholds
+	MetricsEnabled     bool
+	MetricsNamespace   string
+	DigestQueueURL     string
+	DigestSeverities   []finding.SeverityLevel
+	AccountMap         map[string]string
+	AccountLookup      string
+	AccountCacheTTL    time.Duration
+	AccountChannels    map[string]string
+	AccountContext     []string
+	AccountTeamTag     string
+	DryRun             bool
+	ThreadDetails      bool
+	SkipArchived       bool
+	ThreatIntelLinks   bool
+	SkipReobserved     bool
+	Runbooks           string
+	RunbooksPath       string
+	Routes             string
+	RoutesPath         string
+	MaxConcurrency     int
+	IdempotencyTable   string
+	IdempotencyTTL     time.Duration
+
+	EscalationBackend     string
+	EscalationMinSeverity finding.SeverityLevel
+	PagerDutyRoutingKey   string
+	OpsgenieAPIKey        string
+	OpsgenieAPIURL        string
+
+	SlackSigningSecret  string
+	GuardDutyDetectorID string
+
+	StartupCheck bool
+
+	AckEnabled  bool
+	AckTable    string
+	AckFeedback bool
+
+	SummaryTable   string
+	SummaryChannel string
+
+	ArchiveBucket string
+	ArchivePrefix string
+
+	// ParkUnparseable posts events that are not a known finding to slack, or
+	// sends them to ParkQueueURL, instead of failing the invocation.
+	ParkUnparseable bool
+	ParkQueueURL    string
+
+	Locale string
+
+	// TracingEnabled exports opentelemetry spans; the exporter reads the
+	// standard OTEL_* variables.
+	TracingEnabled bool
+
+	// Destinations and DestinationsPath hold the workspace destinations json.
+	Destinations     string
+	DestinationsPath string
+
+	// FloodThreshold findings per FloodWindow post individually; the rest are
+	// collapsed into a storm message. 0 disables flood control.
+	FloodThreshold int
+	FloodWindow    time.Duration
+	FloodTable     string
+
+	// QuietHours holds findings below QuietHoursMinSeverity for the digest.
+	QuietHours            throttle.QuietHours
+	QuietHoursMinSeverity finding.SeverityLevel
+
+	// CorrelationWindow threads findings on the same resource within it under
+	// the first one. 0 disables correlation.
+	CorrelationWindow time.Duration
+	CorrelationTable  string
+}
+
+// Build loads the config from the environment and validates it.
+func Build() (Config, error) {
+	cfg, err := Load()
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Load parses the environment without checking required settings, so callers
+// can adjust the result before calling Validate.
+func Load() (Config, error) {
+	cfg := Config{
+		DebugEnabled:      os.Getenv("APP_DEBUG_ENABLED") == "true",
+		AwsConsoleURL:     os.Getenv("APP_AWS_CONSOLE_URL"),
+		SlackToken:        os.Getenv("APP_SLACK_TOKEN"),
+		SlackChannel:      os.Getenv("APP_SLACK_CHANNEL"),
+		SlackTemplate:     os.Getenv("APP_SLACK_TEMPLATE"),
+		SlackTemplatePath: os.Getenv("APP_SLACK_TEMPLATE_PATH"),
+		MetricsEnabled:    os.Getenv("APP_METRICS_ENABLED") == "true",
+		MetricsNamespace:  os.Getenv("APP_METRICS_NAMESPACE"),
+		ThreadDetails:     os.Getenv("APP_THREAD_DETAILS_ENABLED") != "false",
+		DryRun:            os.Getenv("APP_DRY_RUN") == "true",
+		ThreatIntelLinks:  os.Getenv("APP_THREAT_INTEL_LINKS") == "true",
+		SkipArchived:      os.Getenv("APP_SKIP_ARCHIVED") != "false",
+		SkipReobserved:    os.Getenv("APP_SKIP_REOBSERVED") == "true",
+		Runbooks:          os.Getenv("APP_RUNBOOKS"),
+		RunbooksPath:      os.Getenv("APP_RUNBOOKS_PATH"),
+		Routes:            os.Getenv("APP_ROUTES"),
+		RoutesPath:        os.Getenv("APP_ROUTES_PATH"),
+
+		SlackSigningSecret:  os.Getenv("APP_SLACK_SIGNING_SECRET"),
+		GuardDutyDetectorID: os.Getenv("APP_GUARDDUTY_DETECTOR_ID"),
+
+		StartupCheck: os.Getenv("APP_STARTUP_CHECK") == "true",
+
+		AckEnabled:  os.Getenv("APP_ACK_ENABLED") == "true",
+		AckTable:    os.Getenv("APP_ACK_TABLE"),
+		AckFeedback: os.Getenv("APP_ACK_FEEDBACK") == "true",
+
+		SummaryTable:   os.Getenv("APP_SUMMARY_TABLE"),
+		SummaryChannel: os.Getenv("APP_SUMMARY_CHANNEL"),
+
+		ArchiveBucket: os.Getenv("APP_ARCHIVE_BUCKET"),
+		ArchivePrefix: strings.Trim(os.Getenv("APP_ARCHIVE_PREFIX"), "/"),
+
+		ParkUnparseable: os.Getenv("APP_PARK_UNPARSEABLE") != "false",
+		ParkQueueURL:    os.Getenv("APP_PARK_QUEUE_URL"),
+
+		TracingEnabled: os.Getenv("APP_TRACING_ENABLED") == "true",
+
+		Destinations:     os.Getenv("APP_DESTINATIONS"),
+		DestinationsPath: os.Getenv("APP_DESTINATIONS_PATH"),
+	}
+	if cfg.MetricsNamespace == "" {
+		cfg.MetricsNamespace = "GuardDutySlack"
+	}
+
+	cfg.DigestQueueURL = os.Getenv("APP_DIGEST_QUEUE_URL")
+	cfg.DigestSeverities = []finding.SeverityLevel{finding.SeverityLow, finding.SeverityMedium}
+	if v := os.Getenv("APP_DIGEST_SEVERITIES"); v != "" {
+		cfg.DigestSeverities = nil
+		for _, s := range strings.Split(v, ",") {
+			cfg.DigestSeverities = append(cfg.DigestSeverities, finding.SeverityLevel(strings.TrimSpace(s)))
+		}
+	}
+
+	level, err := logging.ParseLevel(os.Getenv("APP_LOG_LEVEL"))
+	if err != nil {
+		return Config{}, fmt.Errorf("env var APP_LOG_LEVEL: %w", err)
+	}
+	if cfg.DebugEnabled && os.Getenv("APP_LOG_LEVEL") == "" {
+		level = slog.LevelDebug
+	}
+	cfg.LogLevel = level
+
+	if cfg.LogFormat, err = logging.ParseFormat(os.Getenv("APP_LOG_FORMAT")); err != nil {
+		return Config{}, fmt.Errorf("env var APP_LOG_FORMAT: %w", err)
+	}
+
+	cfg.SlackStyle = os.Getenv("APP_SLACK_STYLE")
+	switch cfg.SlackStyle {
+	case "":
+		cfg.SlackStyle = SlackStyleBlocks
+	case SlackStyleBlocks, SlackStyleAttachment:
+	default:
+		return Config{}, fmt.Errorf("env var APP_SLACK_STYLE: unsupported value %q", cfg.SlackStyle)
+	}
+	if err := parseJSONEnv("APP_SEVERITY_EMOJI", &cfg.SeverityEmoji); err != nil {
+		return Config{}, err
+	}
+	cfg.SeverityThresholds = finding.DefaultThresholds
+	if v := os.Getenv("APP_SEVERITY_THRESHOLDS"); v != "" {
+		if cfg.SeverityThresholds, err = finding.ParseThresholds(v); err != nil {
+			return Config{}, fmt.Errorf("env var APP_SEVERITY_THRESHOLDS: %w", err)
+		}
+	}
+	cfg.Locale = cmp.Or(os.Getenv("APP_LOCALE"), i18n.DefaultLocale)
+	if _, err := i18n.Load(cfg.Locale); err != nil {
+		return Config{}, fmt.Errorf("env var APP_LOCALE: %w", err)
+	}
+	cfg.SlackTimeout = 10 * time.Second
+	if v := os.Getenv("APP_SLACK_TIMEOUT"); v != "" {
+		if cfg.SlackTimeout, err = time.ParseDuration(v); err != nil {
+			return Config{}, fmt.Errorf("env var APP_SLACK_TIMEOUT: %w", err)
+		}
+	}
+	cfg.SlackProxyURL = os.Getenv("APP_SLACK_PROXY_URL")
+	cfg.SlackCABundle = os.Getenv("APP_SLACK_CA_BUNDLE")
+
+	if err := parseJSONEnv("APP_ACCOUNT_MAP", &cfg.AccountMap); err != nil {
+		return Config{}, err
+	}
+	if err := parseJSONEnv("APP_ACCOUNT_CHANNELS", &cfg.AccountChannels); err != nil {
+		return Config{}, err
+	}
+	cfg.AccountLookup = os.Getenv("APP_ACCOUNT_LOOKUP")
+	if cfg.AccountLookup != "" && cfg.AccountLookup != "organizations" {
+		return Config{}, fmt.Errorf("env var APP_ACCOUNT_LOOKUP: unsupported value %q", cfg.AccountLookup)
+	}
+	if v := os.Getenv("APP_ACCOUNT_CONTEXT"); v != "" {
+		for _, src := range strings.Split(v, ",") {
+			src = strings.TrimSpace(src)
+			if src != "organizations" && src != "guardduty" {
+				return Config{}, fmt.Errorf("env var APP_ACCOUNT_CONTEXT: unsupported source %q", src)
+			}
+			cfg.AccountContext = append(cfg.AccountContext, src)
+		}
+	}
+	cfg.AccountTeamTag = os.Getenv("APP_ACCOUNT_TEAM_TAG")
+	cfg.MaxConcurrency = 4
+	if v := os.Getenv("APP_MAX_CONCURRENCY"); v != "" {
+		if cfg.MaxConcurrency, err = strconv.Atoi(v); err != nil || cfg.MaxConcurrency < 1 {
+			return Config{}, errors.New("env var APP_MAX_CONCURRENCY: must be a positive integer")
+		}
+	}
+
+	cfg.EscalationBackend = os.Getenv("APP_ESCALATION_BACKEND")
+	if cfg.EscalationBackend != "" && cfg.EscalationBackend != "pagerduty" && cfg.EscalationBackend != "opsgenie" {
+		return Config{}, fmt.Errorf("env var APP_ESCALATION_BACKEND: unsupported value %q", cfg.EscalationBackend)
+	}
+	cfg.EscalationMinSeverity = finding.SeverityCritical
+	if v := os.Getenv("APP_ESCALATION_MIN_SEVERITY"); v != "" {
+		// a custom label or a built-in level.
+		if !cfg.SeverityThresholds.Has(v) {
+			return Config{}, fmt.Errorf("env var APP_ESCALATION_MIN_SEVERITY: unsupported value %q", v)
+		}
+		cfg.EscalationMinSeverity = finding.SeverityLevel(v)
+	}
+	cfg.PagerDutyRoutingKey = os.Getenv("APP_PAGERDUTY_ROUTING_KEY")
+	cfg.OpsgenieAPIKey = os.Getenv("APP_OPSGENIE_API_KEY")
+	cfg.OpsgenieAPIURL = os.Getenv("APP_OPSGENIE_API_URL")
+
+	cfg.IdempotencyTable = os.Getenv("APP_IDEMPOTENCY_TABLE")
+	cfg.IdempotencyTTL = 24 * time.Hour
+	if v := os.Getenv("APP_IDEMPOTENCY_TTL"); v != "" {
+		if cfg.IdempotencyTTL, err = time.ParseDuration(v); err != nil {
+			return Config{}, fmt.Errorf("env var APP_IDEMPOTENCY_TTL: %w", err)
+		}
+	}
+
+	cfg.AccountCacheTTL = time.Hour
+	if v := os.Getenv("APP_ACCOUNT_CACHE_TTL"); v != "" {
+		if cfg.AccountCacheTTL, err = time.ParseDuration(v); err != nil {
+			return Config{}, fmt.Errorf("env var APP_ACCOUNT_CACHE_TTL: %w", err)
+		}
+	}
+
+	if v := os.Getenv("APP_FLOOD_THRESHOLD"); v != "" {
+		if cfg.FloodThreshold, err = strconv.Atoi(v); err != nil || cfg.FloodThreshold < 0 {
+			return Config{}, errors.New("env var APP_FLOOD_THRESHOLD: must be a non-negative integer")
+		}
+	}
+	cfg.FloodWindow = throttle.DefaultWindow
+	if v := os.Getenv("APP_FLOOD_WINDOW"); v != "" {
+		if cfg.FloodWindow, err = time.ParseDuration(v); err != nil || cfg.FloodWindow <= 0 {
+			return Config{}, errors.New("env var APP_FLOOD_WINDOW: must be a positive duration")
+		}
+	}
+	cfg.FloodTable = os.Getenv("APP_FLOOD_TABLE")
+
+	if v := os.Getenv("APP_QUIET_HOURS"); v != "" {
+		if cfg.QuietHours, err = throttle.ParseQuietHours(v, os.Getenv("APP_QUIET_HOURS_TZ")); err != nil {
+			return Config{}, fmt.Errorf("env var APP_QUIET_HOURS: %w", err)
+		}
+	}
+	cfg.QuietHoursMinSeverity = finding.SeverityCritical
+	if v := os.Getenv("APP_QUIET_HOURS_MIN_SEVERITY"); v != "" {
+		if !cfg.SeverityThresholds.Has(v) {
+			return Config{}, fmt.Errorf("env var APP_QUIET_HOURS_MIN_SEVERITY: unsupported value %q", v)
+		}
+		cfg.QuietHoursMinSeverity = finding.SeverityLevel(v)
+	}
+
+	if v := os.Getenv("APP_CORRELATION_WINDOW"); v != "" {
+		if cfg.CorrelationWindow, err = time.ParseDuration(v); err != nil || cfg.CorrelationWindow < 0 {
+			return Config{}, errors.New("env var APP_CORRELATION_WINDOW: must be a non-negative duration")
+		}
+	}
+	cfg.CorrelationTable = os.Getenv("APP_CORRELATION_TABLE")
+	return cfg, nil
+}
+
+// Validate checks required settings and channel ids. dry runs never talk to
+// slack, so the token is optional for them. all problems are returned, joined.
+func (cfg Config) Validate() error {
+	var errs []error
+	if cfg.SlackToken == "" && !cfg.DryRun {
+		errs = append(errs, errors.New("missing env var APP_SLACK_TOKEN"))
+	}
+	if cfg.SlackChannel == "" {
+		errs = append(errs, errors.New("missing env var APP_SLACK_CHANNEL"))
+	} else if !ValidChannelID(cfg.SlackChannel) {
+		errs = append(errs, fmt.Errorf("env var APP_SLACK_CHANNEL: %q is not a channel id", cfg.SlackChannel))
+	}
+	for _, account := range slices.Sorted(maps.Keys(cfg.AccountChannels)) {
+		if ch := cfg.AccountChannels[account]; !ValidChannelID(ch) {
+			errs = append(errs, fmt.Errorf("env var APP_ACCOUNT_CHANNELS: account %s: %q is not a channel id", account, ch))
+		}
+	}
+	if cfg.EscalationBackend == "pagerduty" && cfg.PagerDutyRoutingKey == "" {
+		errs = append(errs, errors.New("missing env var APP_PAGERDUTY_ROUTING_KEY"))
+	}
+	if cfg.EscalationBackend == "opsgenie" && cfg.OpsgenieAPIKey == "" {
+		errs = append(errs, errors.New("missing env var APP_OPSGENIE_API_KEY"))
+	}
+	if cfg.SummaryChannel != "" && !ValidChannelID(cfg.SummaryChannel) {
+		errs = append(errs, fmt.Errorf("env var APP_SUMMARY_CHANNEL: %q is not a channel id", cfg.SummaryChannel))
+	}
+	if cfg.AckEnabled && cfg.SlackSigningSecret == "" {
+		errs = append(errs, errors.New("env var APP_ACK_ENABLED: requires APP_SLACK_SIGNING_SECRET"))
+	}
+	// an unknown label would match nothing and quietly turn the digest off.
+	if cfg.DigestQueueURL != "" {
+		for _, s := range cfg.DigestSeverities {
+			if !cfg.SeverityThresholds.Has(string(s)) {
+				errs = append(errs, fmt.Errorf("env var APP_DIGEST_SEVERITIES: unknown severity %q", s))
+			}
+		}
+	}
+	// held findings go to the digest queue; without one they would be lost.
+	if !cfg.QuietHours.IsZero() && cfg.DigestQueueURL == "" {
+		errs = append(errs, errors.New("env var APP_QUIET_HOURS: requires APP_DIGEST_QUEUE_URL"))
+	}
+	return errors.Join(errs...)
+}
+
+// channelIDRe matches public (C), private (G) and dm (D) conversation ids.
+var channelIDRe = regexp.MustCompile(`^[CGD][A-Z0-9]{6,}$`)
+
+// ValidChannelID reports whether id looks like a slack conversation id rather
+// than a channel name.
+func ValidChannelID(id string) bool {
+	return channelIDRe.MatchString(id)
+}
+
+func parseJSONEnv(name string, v any) error {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		return fmt.Errorf("env var %s: %w", name, err)
+	}
+	return nil
+}
+
+// ReadSource reads a config document from a local path or an s3://bucket/key
+// uri.
+func ReadSource(ctx context.Context, path string) (string, error) {
+	if !strings.HasPrefix(path, "s3://") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read %s: %w", path, err)
+		}
+		return string(data), nil
+	}
+
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(path, "s3://"), "/")
+	if !ok || bucket == "" || key == "" {
+		return "", fmt.Errorf("invalid s3 uri %q", path)
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("load aws config: %w", err)
+	}
+	out, err := s3.NewFromConfig(awsCfg).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", fmt.Errorf("get %s: %w", path, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	return string(data), nil
+}
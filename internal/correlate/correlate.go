@@ -0,0 +1,199 @@
+// correlate.go
+//
+// finding correlation — findings on the same resource within a window share
+// one slack thread. the first opens it with its own message; later ones reply
+// in it, and they and the first note how many related findings it holds.
+
+package correlate
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+// Key identifies the thread of the finding's resource in channel, or is empty
+// when the resource is not known.
+func Key(f finding.Finding, channel string) string {
+	id := f.Resource.ID()
+	if id == "" {
+		return ""
+	}
+	return "thread/" + channel + "/" + f.AccountID + "/" + id
+}
+
+// Thread is the parent message of a resource's findings.
+type Thread struct {
+	TS string
+	// Count includes the parent's own finding.
+	Count int
+}
+
+type Store interface {
+	// Join opens a thread for key, reporting true, unless one is still open
+	// within the window; then the finding is counted in it and the thread is
+	// returned. while the parent is being posted the finding is not counted
+	// and the returned TS is empty, so it posts on its own.
+	Join(ctx context.Context, key string) (Thread, bool, error)
+	// Save records the posted parent of a thread opened by Join.
+	Save(ctx context.Context, key, ts string) error
+	// Release drops a thread whose parent could not be posted.
+	Release(ctx context.Context, key string) error
+}
+
+// ---------------------------------------------------------------- memory ---
+
+// Memory keeps threads per container.
+type Memory struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	threads map[string]*memoryThread
+}
+
+type memoryThread struct {
+	Thread
+	expires time.Time
+}
+
+func NewMemory(window time.Duration) *Memory {
+	return &Memory{window: window, threads: map[string]*memoryThread{}}
+}
+
+func (m *Memory) Join(_ context.Context, key string) (Thread, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if t, ok := m.threads[key]; ok && now.Before(t.expires) {
+		if t.TS != "" {
+			t.Count++
+		}
+		return t.Thread, false, nil
+	}
+	for k, t := range m.threads {
+		if !now.Before(t.expires) {
+			delete(m.threads, k)
+		}
+	}
+	m.threads[key] = &memoryThread{Thread: Thread{Count: 1}, expires: now.Add(m.window)}
+	return Thread{}, true, nil
+}
+
+func (m *Memory) Save(_ context.Context, key, ts string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.threads[key]; ok {
+		t.TS = ts
+	}
+	return nil
+}
+
+func (m *Memory) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.threads, key)
+	return nil
+}
+
+// -------------------------------------------------------------- dynamodb ---
+
+// DynamoDB keeps one item per thread in a table with a string partition key
+// named "id"; enable ttl on "expiresAt". the window runs from the first
+// finding.
+type DynamoDB struct {
+	client *dynamodb.Client
+	table  string
+	window time.Duration
+}
+
+func NewDynamoDB(client *dynamodb.Client, table string, window time.Duration) *DynamoDB {
+	return &DynamoDB{client: client, table: table, window: window}
+}
+
+func (d *DynamoDB) key(key string) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{"id": &ddbtypes.AttributeValueMemberS{Value: key}}
+}
+
+func (d *DynamoDB) Join(ctx context.Context, key string) (Thread, bool, error) {
+	now := time.Now()
+	_, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item: map[string]ddbtypes.AttributeValue{
+			"id":        &ddbtypes.AttributeValueMemberS{Value: key},
+			"count":     &ddbtypes.AttributeValueMemberN{Value: "1"},
+			"expiresAt": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(d.window).Unix(), 10)},
+		},
+		// dynamodb ttl deletion lags, so expired threads are replaced too.
+		ConditionExpression: aws.String("attribute_not_exists(id) OR expiresAt < :now"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":now": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	var condErr *ddbtypes.ConditionalCheckFailedException
+	if err == nil {
+		return Thread{}, true, nil
+	}
+	if !errors.As(err, &condErr) {
+		return Thread{}, false, err
+	}
+
+	out, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 d.key(key),
+		UpdateExpression:    aws.String("ADD #count :one"),
+		ConditionExpression: aws.String("attribute_exists(ts)"),
+		ExpressionAttributeNames: map[string]string{
+			"#count": "count",
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":one": &ddbtypes.AttributeValueMemberN{Value: "1"},
+		},
+		ReturnValues: ddbtypes.ReturnValueAllNew,
+	})
+	if errors.As(err, &condErr) {
+		// the parent is still being posted.
+		return Thread{}, false, nil
+	}
+	if err != nil {
+		return Thread{}, false, err
+	}
+	var t Thread
+	if ts, ok := out.Attributes["ts"].(*ddbtypes.AttributeValueMemberS); ok {
+		t.TS = ts.Value
+	}
+	if n, ok := out.Attributes["count"].(*ddbtypes.AttributeValueMemberN); ok {
+		t.Count, _ = strconv.Atoi(n.Value)
+	}
+	return t, false, nil
+}
+
+func (d *DynamoDB) Save(ctx context.Context, key, ts string) error {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(d.table),
+		Key:              d.key(key),
+		UpdateExpression: aws.String("SET ts = :ts"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":ts": &ddbtypes.AttributeValueMemberS{Value: ts},
+		},
+	})
+	return err
+}
+
+func (d *DynamoDB) Release(ctx context.Context, key string) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.table),
+		Key:       d.key(key),
+	})
+	return err
+}
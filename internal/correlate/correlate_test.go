@@ -0,0 +1,35 @@
+package correlate
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryJoin(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(time.Hour)
+
+	if _, opened, _ := m.Join(ctx, "k"); !opened {
+		t.Fatal("first finding should open the thread")
+	}
+	// posted on its own, so not counted in the thread.
+	if th, opened, _ := m.Join(ctx, "k"); opened || th.TS != "" {
+		t.Fatalf("second finding before save: opened %v, ts %q", opened, th.TS)
+	}
+	m.Save(ctx, "k", "1700000000.000001")
+	th, opened, _ := m.Join(ctx, "k")
+	if opened || th.TS != "1700000000.000001" || th.Count != 2 {
+		t.Fatalf("got opened %v, ts %q, count %d; want the saved thread counting 2", opened, th.TS, th.Count)
+	}
+
+	m.Release(ctx, "k")
+	if _, opened, _ := m.Join(ctx, "k"); !opened {
+		t.Error("a released thread should be opened again")
+	}
+
+	m.threads["k"].expires = time.Now().Add(-time.Second)
+	if _, opened, _ := m.Join(ctx, "k"); !opened {
+		t.Error("an expired thread should be opened again")
+	}
+}
@@ -0,0 +1,171 @@
+// dedupe.go
+//
+// idempotency guard — eventbridge delivers at least once, so findings are
+// claimed by id + updatedAt before posting and exact duplicates are skipped.
+// an in-memory lru catches same-container retries; dynamodb covers the rest.
+
+package dedupe
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+// DefaultCapacity bounds the in-memory store.
+const DefaultCapacity = 4096
+
+type Store interface {
+	// Claim records key and reports whether it was new. false means the key
+	// was already claimed and the finding is a duplicate.
+	Claim(ctx context.Context, key string) (bool, error)
+	// Release forgets key so a failed delivery can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// Key identifies one revision of a finding. guardduty bumps updatedAt when a
+// finding recurs, so updates are still delivered. the account is part of the
+// key because org-wide health events share one event arn.
+func Key(f finding.Finding) string {
+	return f.AccountID + "/" + f.ID + "@" + f.UpdatedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// ---------------------------------------------------------------- memory ---
+
+type memoryEntry struct {
+	key     string
+	expires time.Time
+}
+
+type Memory struct {
+	ttl      time.Duration
+	capacity int
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+func NewMemory(ttl time.Duration, capacity int) *Memory {
+	return &Memory{
+		ttl:      ttl,
+		capacity: capacity,
+		order:    list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+func (m *Memory) Claim(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if el, ok := m.items[key]; ok {
+		if now.Before(el.Value.(memoryEntry).expires) {
+			return false, nil
+		}
+		m.remove(el)
+	}
+
+	m.items[key] = m.order.PushFront(memoryEntry{key: key, expires: now.Add(m.ttl)})
+	for m.order.Len() > m.capacity {
+		m.remove(m.order.Back())
+	}
+	return true, nil
+}
+
+func (m *Memory) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+	return nil
+}
+
+func (m *Memory) remove(el *list.Element) {
+	m.order.Remove(el)
+	delete(m.items, el.Value.(memoryEntry).key)
+}
+
+// -------------------------------------------------------------- dynamodb ---
+
+// DynamoDB claims keys with a conditional put. the table needs a string
+// partition key named "id"; enable ttl on "expiresAt" to expire old claims.
+type DynamoDB struct {
+	client *dynamodb.Client
+	table  string
+	ttl    time.Duration
+}
+
+func NewDynamoDB(client *dynamodb.Client, table string, ttl time.Duration) *DynamoDB {
+	return &DynamoDB{client: client, table: table, ttl: ttl}
+}
+
+func (d *DynamoDB) Claim(ctx context.Context, key string) (bool, error) {
+	now := time.Now()
+	_, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item: map[string]ddbtypes.AttributeValue{
+			"id":        &ddbtypes.AttributeValueMemberS{Value: key},
+			"expiresAt": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(d.ttl).Unix(), 10)},
+		},
+		// dynamodb ttl deletion lags, so expired claims are overwritten too.
+		ConditionExpression: aws.String("attribute_not_exists(id) OR expiresAt < :now"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":now": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	var condErr *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *DynamoDB) Release(ctx context.Context, key string) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.table),
+		Key: map[string]ddbtypes.AttributeValue{
+			"id": &ddbtypes.AttributeValueMemberS{Value: key},
+		},
+	})
+	return err
+}
+
+// ----------------------------------------------------------------- chain ---
+
+// Chain claims a key in every store in order and stops at the first one that
+// has already seen it, so a cheap local store can shield a remote one.
+type Chain []Store
+
+func (c Chain) Claim(ctx context.Context, key string) (bool, error) {
+	for _, s := range c {
+		ok, err := s.Claim(ctx, key)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (c Chain) Release(ctx context.Context, key string) error {
+	var errs []error
+	for _, s := range c {
+		errs = append(errs, s.Release(ctx, key))
+	}
+	return errors.Join(errs...)
+}
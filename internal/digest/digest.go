@@ -0,0 +1,243 @@
+// digest.go
+//
+// digest mode — findings at digest severities are buffered in sqs and posted
+// as a single summary message when a scheduled eventbridge rule invokes the
+// function.
+
+package digest
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+const (
+	// MaxEntries caps how many buffered findings a single digest summarizes.
+	MaxEntries = 1000
+	topN       = 5
+)
+
+type Entry struct {
+	ID            string                `json:"id"`
+	Type          string                `json:"type"`
+	Title         string                `json:"title"`
+	AccountID     string                `json:"accountId"`
+	AccountName   string                `json:"accountName,omitempty"`
+	Region        string                `json:"region"`
+	Severity      float64               `json:"severity"`
+	SeverityLabel finding.SeverityLevel `json:"severityLabel"`
+	SeverityName  string                `json:"severityName"`
+	ResourceID    string                `json:"resourceId,omitempty"`
+	UpdatedAt     time.Time             `json:"updatedAt"`
+	// Destination names the workspace destination to post to; empty is the
+	// default workspace.
+	Destination string `json:"destination,omitempty"`
+
+	receipt string
+}
+
+func NewEntry(f finding.Finding) Entry {
+	return Entry{
+		ID:            f.ID,
+		Type:          f.Type,
+		Title:         f.Title,
+		AccountID:     f.AccountID,
+		AccountName:   f.AccountName,
+		Region:        f.Region,
+		Severity:      f.Severity,
+		SeverityLabel: f.SeverityLabel,
+		SeverityName:  f.SeverityName,
+		ResourceID:    f.Resource.ID(),
+		UpdatedAt:     f.UpdatedAt,
+	}
+}
+
+func (e Entry) AccountDisplay() string {
+	return finding.Finding{AccountID: e.AccountID, AccountName: e.AccountName}.AccountDisplay()
+}
+
+type Store interface {
+	Add(ctx context.Context, e Entry) error
+	// Receive returns up to max buffered entries without removing them.
+	Receive(ctx context.Context, max int) ([]Entry, error)
+	// Remove deletes entries previously returned by Receive.
+	Remove(ctx context.Context, entries []Entry) error
+}
+
+// ------------------------------------------------------------------- sqs ---
+
+type SQSStore struct {
+	client   *sqs.Client
+	queueURL string
+}
+
+func NewSQSStore(client *sqs.Client, queueURL string) *SQSStore {
+	return &SQSStore{client: client, queueURL: queueURL}
+}
+
+func (s *SQSStore) Add(ctx context.Context, e Entry) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(s.queueURL),
+		MessageBody: aws.String(string(body)),
+	})
+	if err != nil {
+		return fmt.Errorf("enqueue digest entry: %w", err)
+	}
+	return nil
+}
+
+func (s *SQSStore) Receive(ctx context.Context, max int) ([]Entry, error) {
+	var entries []Entry
+	for len(entries) < max {
+		out, err := s.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(s.queueURL),
+			MaxNumberOfMessages: int32(min(10, max-len(entries))),
+			VisibilityTimeout:   300,
+		})
+		if err != nil {
+			return entries, fmt.Errorf("receive digest entries: %w", err)
+		}
+		if len(out.Messages) == 0 {
+			break
+		}
+		for _, m := range out.Messages {
+			var e Entry
+			if err := json.Unmarshal([]byte(aws.ToString(m.Body)), &e); err != nil {
+				// keep the receipt so malformed messages are cleared with the batch
+				e = Entry{ID: aws.ToString(m.MessageId)}
+			}
+			e.receipt = aws.ToString(m.ReceiptHandle)
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (s *SQSStore) Remove(ctx context.Context, entries []Entry) error {
+	for batch := range slices.Chunk(entries, 10) {
+		reqs := make([]sqstypes.DeleteMessageBatchRequestEntry, 0, len(batch))
+		for i, e := range batch {
+			reqs = append(reqs, sqstypes.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(fmt.Sprint(i)),
+				ReceiptHandle: aws.String(e.receipt),
+			})
+		}
+		out, err := s.client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(s.queueURL),
+			Entries:  reqs,
+		})
+		if err != nil {
+			return fmt.Errorf("delete digest entries: %w", err)
+		}
+		if len(out.Failed) > 0 {
+			return fmt.Errorf("delete digest entries: %d failed", len(out.Failed))
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------- render ---
+
+type keyCount struct {
+	Key   string
+	Count int
+}
+
+func topCounts(entries []Entry, key func(Entry) string, n int) []keyCount {
+	counts := map[string]int{}
+	for _, e := range entries {
+		if k := key(e); k != "" {
+			counts[k]++
+		}
+	}
+	out := make([]keyCount, 0, len(counts))
+	for _, k := range slices.Sorted(maps.Keys(counts)) {
+		out = append(out, keyCount{Key: k, Count: counts[k]})
+	}
+	slices.SortStableFunc(out, func(a, b keyCount) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+func formatCounts(title string, counts []keyCount) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*", title)
+	for _, c := range counts {
+		fmt.Fprintf(&b, "\n• `%s` — %d", c.Key, c.Count)
+	}
+	return b.String()
+}
+
+func Render(entries []Entry) []slack.Block {
+	var oldest, newest time.Time
+	for _, e := range entries {
+		if e.UpdatedAt.IsZero() {
+			continue
+		}
+		if oldest.IsZero() || e.UpdatedAt.Before(oldest) {
+			oldest = e.UpdatedAt
+		}
+		if e.UpdatedAt.After(newest) {
+			newest = e.UpdatedAt
+		}
+	}
+
+	header := slack.NewHeaderBlock(slack.NewTextBlockObject(
+		"plain_text", fmt.Sprintf("GuardDuty digest: %d findings", len(entries)), true, false,
+	))
+	blocks := []slack.Block{header}
+	if !oldest.IsZero() {
+		blocks = append(blocks, slack.NewContextBlock("window", slack.NewTextBlockObject(
+			"mrkdwn",
+			fmt.Sprintf("%s → %s", oldest.UTC().Format(time.RFC3339), newest.UTC().Format(time.RFC3339)),
+			false, false,
+		)))
+	}
+
+	var sevFields []*slack.TextBlockObject
+	for _, c := range topCounts(entries, func(e Entry) string { return e.SeverityName }, 0) {
+		sevFields = append(sevFields, slack.NewTextBlockObject(
+			"mrkdwn", fmt.Sprintf("*%s:* %d", c.Key, c.Count), false, false,
+		))
+	}
+
+	if len(sevFields) > 0 {
+		blocks = append(blocks, slack.NewSectionBlock(nil, sevFields, nil))
+	}
+	blocks = append(blocks,
+		slack.NewDividerBlock(),
+		slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn",
+			formatCounts("By type", topCounts(entries, func(e Entry) string { return e.Type }, topN*2)),
+			false, false), nil, nil),
+		slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn",
+			formatCounts("By account", topCounts(entries, func(e Entry) string { return e.AccountDisplay() }, topN*2)),
+			false, false), nil, nil),
+	)
+	if offenders := topCounts(entries, func(e Entry) string { return e.ResourceID }, topN); len(offenders) > 0 {
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn",
+			formatCounts("Top offenders", offenders),
+			false, false), nil, nil))
+	}
+	return blocks
+}
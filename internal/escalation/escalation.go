@@ -0,0 +1,183 @@
+// escalation.go
+//
+// incident escalation — findings at or above APP_ESCALATION_MIN_SEVERITY also
+// page through pagerduty (events api v2) or opsgenie. both dedupe on the
+// finding id, so retries and updates land on the same incident.
+
+package escalation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/slackout"
+)
+
+const (
+	BackendPagerDuty = "pagerduty"
+	BackendOpsgenie  = "opsgenie"
+
+	pagerDutyURL       = "https://events.pagerduty.com/v2/enqueue"
+	DefaultOpsgenieURL = "https://api.opsgenie.com"
+	requestTimeout     = 10 * time.Second
+)
+
+type Escalator interface {
+	Escalate(ctx context.Context, f finding.Finding) error
+}
+
+// DedupKey ties every revision of a finding to one incident.
+func DedupKey(f finding.Finding) string {
+	return f.Source + "-" + f.ID
+}
+
+func details(f finding.Finding) map[string]any {
+	return map[string]any{
+		"finding_id":   f.ID,
+		"finding_type": f.Type,
+		"severity":     f.Severity,
+		"account":      f.AccountDisplay(),
+		"region":       f.Region,
+		"resource":     f.Resource.ID(),
+		"description":  f.Description,
+		"console_url":  f.ConsoleURL,
+	}
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: status %d: %s", url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// ------------------------------------------------------------- pagerduty ---
+
+type PagerDuty struct {
+	client     *http.Client
+	routingKey string
+}
+
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{client: &http.Client{Timeout: requestTimeout}, routingKey: routingKey}
+}
+
+func (p *PagerDuty) Escalate(ctx context.Context, f finding.Finding) error {
+	event := map[string]any{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    DedupKey(f),
+		"payload": map[string]any{
+			"summary":        slackout.Truncate(fmt.Sprintf("[%s] %s (%s)", f.SeverityName, f.Title, f.AccountDisplay()), 1024),
+			"source":         f.Source + "/" + f.Region,
+			"severity":       pagerDutySeverity(f.SeverityLabel),
+			"component":      f.Resource.ID(),
+			"group":          f.AccountID,
+			"class":          f.Type,
+			"custom_details": details(f),
+		},
+		"links": []map[string]string{{"href": f.ConsoleURL, "text": "View in GuardDuty"}},
+	}
+	if err := postJSON(ctx, p.client, pagerDutyURL, http.Header{}, event); err != nil {
+		return fmt.Errorf("pagerduty enqueue: %w", err)
+	}
+	return nil
+}
+
+func pagerDutySeverity(level finding.SeverityLevel) string {
+	switch level {
+	case finding.SeverityCritical:
+		return "critical"
+	case finding.SeverityHigh:
+		return "error"
+	case finding.SeverityMedium:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// -------------------------------------------------------------- opsgenie ---
+
+type Opsgenie struct {
+	client  *http.Client
+	apiKey  string
+	baseURL string
+}
+
+// NewOpsgenie creates an opsgenie alerter. baseURL selects the region
+// (https://api.eu.opsgenie.com for eu accounts).
+func NewOpsgenie(apiKey, baseURL string) *Opsgenie {
+	if baseURL == "" {
+		baseURL = DefaultOpsgenieURL
+	}
+	return &Opsgenie{
+		client:  &http.Client{Timeout: requestTimeout},
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (o *Opsgenie) Escalate(ctx context.Context, f finding.Finding) error {
+	alert := map[string]any{
+		"message":     slackout.Truncate(f.Title, 130),
+		"alias":       DedupKey(f),
+		"description": slackout.Truncate(f.Description+"\n\n"+f.ConsoleURL, 15000),
+		"priority":    opsgeniePriority(f.SeverityLabel),
+		"source":      f.Source,
+		"entity":      f.Resource.ID(),
+		"tags":        []string{f.Source, string(f.SeverityLabel), f.AccountID},
+		"details":     stringDetails(details(f)),
+	}
+	header := http.Header{"Authorization": []string{"GenieKey " + o.apiKey}}
+	if err := postJSON(ctx, o.client, o.baseURL+"/v2/alerts", header, alert); err != nil {
+		return fmt.Errorf("opsgenie create alert: %w", err)
+	}
+	return nil
+}
+
+func opsgeniePriority(level finding.SeverityLevel) string {
+	switch level {
+	case finding.SeverityCritical:
+		return "P1"
+	case finding.SeverityHigh:
+		return "P2"
+	case finding.SeverityMedium:
+		return "P3"
+	default:
+		return "P4"
+	}
+}
+
+// opsgenie only accepts string values in details.
+func stringDetails(m map[string]any) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+// console.go
+//
+// console links — the console origin is derived from the finding's partition
+// and region so multi-region and govcloud/china findings link correctly.
+
+package finding
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ConsoleOrigin returns the console origin for a partition and region. an
+// empty partition is inferred from the region name.
+func ConsoleOrigin(partition, region string) string {
+	if partition == "" {
+		partition = partitionForRegion(region)
+	}
+	switch partition {
+	case "aws-us-gov":
+		return "https://console.amazonaws-us-gov.com"
+	case "aws-cn":
+		return "https://console.amazonaws.cn"
+	}
+	if region == "" {
+		return "https://console.aws.amazon.com"
+	}
+	return fmt.Sprintf("https://%s.console.aws.amazon.com", region)
+}
+
+func partitionForRegion(region string) string {
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	default:
+		return "aws"
+	}
+}
+
+// ConsoleURL links to a finding in the guardduty console. an empty
+// consoleBase derives the origin from the region.
+func ConsoleURL(consoleBase, region, id string) string {
+	if consoleBase == "" {
+		consoleBase = ConsoleOrigin("", region)
+	}
+	return fmt.Sprintf(
+		"%s/guardduty/home?region=%s#/findings?&macros=current&fId=%s",
+		consoleBase, region, id,
+	)
+}
+
+type ConsoleLink struct {
+	Label string
+	URL   string
+}
+
+// ResourceLinks deep-links to the affected resources in their own consoles.
+func (f Finding) ResourceLinks() []ConsoleLink {
+	origin, region := f.ConsoleOrigin, url.QueryEscape(f.Region)
+	var links []ConsoleLink
+	r := f.Resource
+
+	if r.InstanceDetails != nil && r.InstanceDetails.InstanceID != "" {
+		links = append(links, ConsoleLink{"Instance", fmt.Sprintf(
+			"%s/ec2/home?region=%s#InstanceDetails:instanceId=%s",
+			origin, region, url.PathEscape(r.InstanceDetails.InstanceID),
+		)})
+	}
+	if ak := r.AccessKeyDetails; ak != nil && ak.UserType == "IAMUser" && ak.UserName != "" {
+		links = append(links, ConsoleLink{"IAM User", fmt.Sprintf(
+			"%s/iam/home#/users/details/%s", origin, url.PathEscape(ak.UserName),
+		)})
+	}
+	if len(r.S3BucketDetails) > 0 && r.S3BucketDetails[0].Name != "" {
+		links = append(links, ConsoleLink{"S3 Bucket", fmt.Sprintf(
+			"%s/s3/buckets/%s?region=%s", origin, url.PathEscape(r.S3BucketDetails[0].Name), region,
+		)})
+	}
+	if r.EksClusterDetails != nil && r.EksClusterDetails.Name != "" {
+		links = append(links, ConsoleLink{"EKS Cluster", fmt.Sprintf(
+			"%s/eks/home?region=%s#/clusters/%s", origin, region, url.PathEscape(r.EksClusterDetails.Name),
+		)})
+	}
+	return links
+}
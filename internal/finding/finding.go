@@ -0,0 +1,215 @@
+// finding.go
+//
+// finding model — the subset of the guardduty finding schema used for
+// rendering, routing and enrichment.
+
+package finding
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type SeverityLevel string
+
+const (
+	SeverityUnknown  SeverityLevel = "unknown"
+	SeverityLow      SeverityLevel = "low"
+	SeverityMedium   SeverityLevel = "medium"
+	SeverityHigh     SeverityLevel = "high"
+	SeverityCritical SeverityLevel = "critical"
+)
+
+const (
+	SourceGuardDuty = "guardduty"
+	SourceInspector = "inspector2"
+	SourceHealth    = "health"
+)
+
+// detail types of the supported eventbridge events.
+const (
+	DetailTypeGuardDuty = "GuardDuty Finding"
+	DetailTypeInspector = "Inspector2 Finding"
+	DetailTypeHealth    = "AWS Health Event"
+)
+
+type Finding struct {
+	ID            string        `json:"id"`
+	AccountID     string        `json:"accountId"`
+	Region        string        `json:"region"`
+	Partition     string        `json:"partition"`
+	Type          string        `json:"type"`
+	Title         string        `json:"title"`
+	Description   string        `json:"description"`
+	Severity      float64       `json:"severity"`
+	Resource      Resource      `json:"resource"`
+	Service       Service       `json:"service"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
+	SeverityLabel SeverityLevel `json:"-"`
+	// SeverityName is the display label, e.g. "P1" with custom thresholds.
+	SeverityName  string `json:"-"`
+	AccountName   string `json:"-"`
+	AccountEmail  string `json:"-"`
+	AccountOU     string `json:"-"`
+	AccountTeam   string `json:"-"`
+	ConsoleURL    string `json:"-"`
+	ConsoleOrigin string `json:"-"`
+	DetailType    string `json:"-"`
+	// Source is the producing service; Inspector and Health carry their
+	// source-specific fields.
+	Source    string            `json:"-"`
+	Inspector *InspectorDetails `json:"-"`
+	Health    *HealthDetails    `json:"-"`
+	Raw       json.RawMessage
+
+	// AccountTags are the account's organizations tags, when looked up.
+	AccountTags map[string]string `json:"-"`
+}
+
+type Resource struct {
+	ResourceType     string            `json:"resourceType"`
+	AccessKeyDetails *AccessKeyDetails `json:"accessKeyDetails,omitempty"`
+	InstanceDetails  *InstanceDetails  `json:"instanceDetails,omitempty"`
+	S3BucketDetails  []S3BucketDetail  `json:"s3BucketDetails,omitempty"`
+
+	EbsVolumeDetails  *EbsVolumeDetails  `json:"ebsVolumeDetails,omitempty"`
+	KubernetesDetails *KubernetesDetails `json:"kubernetesDetails,omitempty"`
+	EksClusterDetails *EksClusterDetails `json:"eksClusterDetails,omitempty"`
+	EcsClusterDetails *EcsClusterDetails `json:"ecsClusterDetails,omitempty"`
+}
+
+type Service struct {
+	Action     json.RawMessage `json:"action,omitempty"`
+	DetectorID string          `json:"detectorId,omitempty"`
+	Count      int             `json:"count"`
+	Archived   bool            `json:"archived"`
+	Evidence   *Evidence       `json:"evidence,omitempty"`
+	// AdditionalInfo is free-form and varies by finding type.
+	AdditionalInfo json.RawMessage `json:"additionalInfo,omitempty"`
+	FeatureName    string          `json:"featureName,omitempty"`
+	// EventFirstSeen and EventLastSeen bound the observed activity; guardduty
+	// aggregates repeats into one finding and bumps Count.
+	EventFirstSeen string `json:"eventFirstSeen,omitempty"`
+	EventLastSeen  string `json:"eventLastSeen,omitempty"`
+
+	EbsVolumeScanDetails *EbsVolumeScanDetails `json:"ebsVolumeScanDetails,omitempty"`
+	RuntimeDetails       *RuntimeDetails       `json:"runtimeDetails,omitempty"`
+}
+
+type AccessKeyDetails struct {
+	AccessKeyID string `json:"accessKeyId"`
+	PrincipalID string `json:"principalId"`
+	UserName    string `json:"userName"`
+	UserType    string `json:"userType"`
+}
+
+type InstanceDetails struct {
+	InstanceID   string `json:"instanceId"`
+	InstanceType string `json:"instanceType"`
+}
+
+type S3BucketDetail struct {
+	Name string `json:"name"`
+	Arn  string `json:"arn"`
+}
+
+// ParseEvent decodes an eventbridge event detail according to its detail
+// type. an empty detail type is treated as a guardduty finding. a detail that
+// decodes but carries no id is rejected.
+func ParseEvent(detailType string, raw json.RawMessage, consoleBase string) (Finding, error) {
+	var (
+		f   Finding
+		err error
+	)
+	switch detailType {
+	case "", DetailTypeGuardDuty:
+		f, err = Parse(raw, consoleBase)
+	case DetailTypeInspector:
+		f, err = ParseInspector(raw, consoleBase)
+	case DetailTypeHealth:
+		f, err = ParseHealth(raw)
+	default:
+		return Finding{}, fmt.Errorf("unsupported detail-type %q", detailType)
+	}
+	if err != nil {
+		return Finding{}, err
+	}
+	// any json object decodes; without an id it is not a finding at all.
+	if f.ID == "" {
+		return Finding{}, fmt.Errorf("%s detail has no finding id", f.Source)
+	}
+	f.DetailType = detailType
+	return f, nil
+}
+
+// Parse decodes a guardduty finding from an eventbridge event detail and
+// fills in the derived fields. consoleBase overrides the console origin derived from the
+// finding's partition and region.
+func Parse(raw json.RawMessage, consoleBase string) (Finding, error) {
+	var f Finding
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return Finding{}, err
+	}
+	f.ConsoleOrigin = consoleBase
+	if f.ConsoleOrigin == "" {
+		f.ConsoleOrigin = ConsoleOrigin(f.Partition, f.Region)
+	}
+	f.ConsoleURL = ConsoleURL(f.ConsoleOrigin, f.Region, f.ID)
+	f.Raw = raw
+	f.Source = SourceGuardDuty
+	f.SeverityLabel, f.SeverityName = DefaultThresholds.Classify(f.Severity)
+	return f, nil
+}
+
+// ID returns the most specific identifier of the affected resource, or an
+// empty string when the resource type is not recognized.
+func (r Resource) ID() string {
+	switch {
+	case r.InstanceDetails != nil && r.InstanceDetails.InstanceID != "":
+		return r.InstanceDetails.InstanceID
+	case r.AccessKeyDetails != nil && r.AccessKeyDetails.UserName != "":
+		return r.AccessKeyDetails.UserName
+	case r.AccessKeyDetails != nil && r.AccessKeyDetails.PrincipalID != "":
+		return r.AccessKeyDetails.PrincipalID
+	case len(r.S3BucketDetails) > 0:
+		return r.S3BucketDetails[0].Name
+	case r.EksClusterDetails != nil && r.EksClusterDetails.Name != "":
+		return r.EksClusterDetails.Name
+	case r.EcsClusterDetails != nil && r.EcsClusterDetails.Name != "":
+		return r.EcsClusterDetails.Name
+	case r.EbsVolumeDetails != nil && len(r.EbsVolumeDetails.ScannedVolumeDetails) > 0:
+		return r.EbsVolumeDetails.ScannedVolumeDetails[0].VolumeArn
+	default:
+		return ""
+	}
+}
+
+// Reobserved reports whether this event is an update of an existing finding
+// whose activity was seen again, rather than a new finding.
+func (f Finding) Reobserved() bool {
+	return f.Service.Count > 1
+}
+
+// AccountDisplay renders the account as "name (id)" when a name is known.
+func (f Finding) AccountDisplay() string {
+	if f.AccountName == "" {
+		return f.AccountID
+	}
+	return fmt.Sprintf("%s (%s)", f.AccountName, f.AccountID)
+}
+
+// AccountContext summarizes who owns the account, e.g.
+// "Team: payments · OU: Workloads · Contact: aws+pay@example.com". empty when
+// nothing is known.
+func (f Finding) AccountContext() string {
+	var parts []string
+	for _, kv := range [][2]string{{"Team", f.AccountTeam}, {"OU", f.AccountOU}, {"Contact", f.AccountEmail}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+": "+kv[1])
+		}
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,103 @@
+// health.go
+//
+// aws health events — service issues, scheduled changes and account
+// notifications normalized into the common Finding model.
+
+package finding
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type HealthDetails struct {
+	EventArn          string   `json:"eventArn"`
+	Service           string   `json:"service"`
+	EventTypeCode     string   `json:"eventTypeCode"`
+	EventTypeCategory string   `json:"eventTypeCategory"`
+	StatusCode        string   `json:"statusCode"`
+	StartTime         string   `json:"startTime"`
+	EndTime           string   `json:"endTime,omitempty"`
+	AffectedEntities  []string `json:"-"`
+}
+
+type healthEvent struct {
+	HealthDetails
+	EventRegion      string `json:"eventRegion"`
+	AffectedAccount  string `json:"affectedAccount"`
+	LastUpdatedTime  string `json:"lastUpdatedTime"`
+	EventDescription []struct {
+		Language          string `json:"language"`
+		LatestDescription string `json:"latestDescription"`
+	} `json:"eventDescription"`
+	AffectedEntities []struct {
+		EntityValue string `json:"entityValue"`
+	} `json:"affectedEntities"`
+}
+
+// health categories mapped onto the guardduty 0-10 scale.
+var healthSeverities = map[string]float64{
+	"issue":               7,
+	"scheduledChange":     4,
+	"accountNotification": 1,
+}
+
+// ParseHealth decodes an "AWS Health Event" detail.
+func ParseHealth(raw json.RawMessage) (Finding, error) {
+	var e healthEvent
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return Finding{}, err
+	}
+	for _, ent := range e.AffectedEntities {
+		e.HealthDetails.AffectedEntities = append(e.HealthDetails.AffectedEntities, ent.EntityValue)
+	}
+
+	f := Finding{
+		Source:    SourceHealth,
+		ID:        e.EventArn,
+		AccountID: e.AffectedAccount,
+		Region:    e.EventRegion,
+		Type:      "Health:" + e.EventTypeCode,
+		Title:     healthTitle(e.Service, e.EventTypeCode),
+		Severity:  healthSeverities[e.EventTypeCategory],
+		CreatedAt: parseHealthTime(e.StartTime),
+		UpdatedAt: parseHealthTime(e.LastUpdatedTime),
+		Health:    &e.HealthDetails,
+		Raw:       raw,
+	}
+	for _, d := range e.EventDescription {
+		if f.Description == "" || d.Language == "en_US" {
+			f.Description = d.LatestDescription
+		}
+	}
+	f.Service.Archived = e.StatusCode == "closed"
+
+	f.ConsoleOrigin = "https://health.aws.amazon.com"
+	f.ConsoleURL = fmt.Sprintf("%s/health/home#/account/event-log?eventID=%s&eventTab=details",
+		f.ConsoleOrigin, url.QueryEscape(e.EventArn))
+	f.SeverityLabel, f.SeverityName = DefaultThresholds.Classify(f.Severity)
+	return f, nil
+}
+
+// healthTitle turns AWS_EC2_INSTANCE_RETIREMENT_SCHEDULED into
+// "EC2: Instance retirement scheduled".
+func healthTitle(service, code string) string {
+	words := strings.TrimPrefix(code, "AWS_"+service+"_")
+	words = strings.ToLower(strings.ReplaceAll(words, "_", " "))
+	if words != "" {
+		words = strings.ToUpper(words[:1]) + words[1:]
+	}
+	return service + ": " + words
+}
+
+// health timestamps are rfc 1123 ("Sat, 05 Jun 2016 15:10:09 GMT").
+func parseHealthTime(s string) time.Time {
+	t, err := time.Parse(time.RFC1123, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,113 @@
+// inspector.go
+//
+// amazon inspector2 findings — vulnerability and network reachability
+// findings normalized into the common Finding model.
+
+package finding
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type InspectorDetails struct {
+	FindingArn     string              `json:"findingArn"`
+	Status         string              `json:"status"`
+	FixAvailable   string              `json:"fixAvailable"`
+	InspectorScore float64             `json:"inspectorScore"`
+	Resources      []InspectorResource `json:"resources"`
+
+	PackageVulnerabilityDetails *struct {
+		VulnerabilityID    string `json:"vulnerabilityId"`
+		SourceURL          string `json:"sourceUrl"`
+		VulnerablePackages []struct {
+			Name           string `json:"name"`
+			Version        string `json:"version"`
+			FixedInVersion string `json:"fixedInVersion"`
+		} `json:"vulnerablePackages"`
+	} `json:"packageVulnerabilityDetails,omitempty"`
+
+	Remediation struct {
+		Recommendation struct {
+			Text string `json:"text"`
+			URL  string `json:"Url"`
+		} `json:"recommendation"`
+	} `json:"remediation"`
+}
+
+type InspectorResource struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Region    string `json:"region"`
+	Partition string `json:"partition"`
+}
+
+type inspectorEvent struct {
+	InspectorDetails
+	AwsAccountID    string    `json:"awsAccountId"`
+	Type            string    `json:"type"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	Severity        string    `json:"severity"`
+	FirstObservedAt time.Time `json:"firstObservedAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// inspector labels mapped onto the guardduty 0-10 scale, used when the event
+// has no inspectorScore.
+var inspectorSeverities = map[string]float64{
+	"CRITICAL":      9,
+	"HIGH":          7,
+	"MEDIUM":        4,
+	"LOW":           1,
+	"INFORMATIONAL": 0,
+	"UNTRIAGED":     0,
+}
+
+// ParseInspector decodes an "Inspector2 Finding" event detail.
+func ParseInspector(raw json.RawMessage, consoleBase string) (Finding, error) {
+	var e inspectorEvent
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return Finding{}, err
+	}
+
+	f := Finding{
+		Source:      SourceInspector,
+		ID:          e.FindingArn,
+		AccountID:   e.AwsAccountID,
+		Type:        "Inspector2:" + e.Type,
+		Title:       e.Title,
+		Description: e.Description,
+		Severity:    e.InspectorScore,
+		CreatedAt:   e.FirstObservedAt,
+		UpdatedAt:   e.UpdatedAt,
+		Inspector:   &e.InspectorDetails,
+		Raw:         raw,
+	}
+	if f.Severity == 0 {
+		f.Severity = inspectorSeverities[strings.ToUpper(e.Severity)]
+	}
+	// suppressed and closed findings need no action, like archived ones.
+	f.Service.Archived = e.Status == "SUPPRESSED" || e.Status == "CLOSED"
+
+	if len(e.Resources) > 0 {
+		r := e.Resources[0]
+		f.Region, f.Partition = r.Region, r.Partition
+		f.Resource.ResourceType = r.Type
+		if r.Type == "AWS_EC2_INSTANCE" {
+			f.Resource.InstanceDetails = &InstanceDetails{InstanceID: r.ID}
+		}
+	}
+
+	f.ConsoleOrigin = consoleBase
+	if f.ConsoleOrigin == "" {
+		f.ConsoleOrigin = ConsoleOrigin(f.Partition, f.Region)
+	}
+	f.ConsoleURL = fmt.Sprintf("%s/inspector/v2/home?region=%s#/findings/all?search=%s",
+		f.ConsoleOrigin, f.Region, url.QueryEscape("findingArn="+e.FindingArn))
+	f.SeverityLabel, f.SeverityName = DefaultThresholds.Classify(f.Severity)
+	return f, nil
+}
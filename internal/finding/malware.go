@@ -0,0 +1,64 @@
+// malware.go
+//
+// malware protection — scan results attached to findings raised by guardduty
+// malware protection for ec2 (ebs volume scans).
+
+package finding
+
+type EbsVolumeDetails struct {
+	ScannedVolumeDetails []VolumeDetail `json:"scannedVolumeDetails,omitempty"`
+	SkippedVolumeDetails []VolumeDetail `json:"skippedVolumeDetails,omitempty"`
+}
+
+type VolumeDetail struct {
+	VolumeArn      string `json:"volumeArn"`
+	VolumeType     string `json:"volumeType"`
+	DeviceName     string `json:"deviceName"`
+	VolumeSizeInGB int    `json:"volumeSizeInGB"`
+	EncryptionType string `json:"encryptionType"`
+	SnapshotArn    string `json:"snapshotArn"`
+}
+
+type EbsVolumeScanDetails struct {
+	ScanID           string         `json:"scanId"`
+	TriggerFindingID string         `json:"triggerFindingId"`
+	ScanType         string         `json:"scanType"`
+	Sources          []string       `json:"sources,omitempty"`
+	ScanDetections   ScanDetections `json:"scanDetections"`
+}
+
+type ScanDetections struct {
+	ScannedItemCount struct {
+		TotalGb int `json:"totalGb"`
+		Files   int `json:"files"`
+		Volumes int `json:"volumes"`
+	} `json:"scannedItemCount"`
+	ThreatsDetectedItemCount struct {
+		Files int `json:"files"`
+	} `json:"threatsDetectedItemCount"`
+	HighestSeverityThreatDetails struct {
+		Severity   string `json:"severity"`
+		ThreatName string `json:"threatName"`
+		Count      int    `json:"count"`
+	} `json:"highestSeverityThreatDetails"`
+	ThreatDetectedByName struct {
+		ItemCount             int          `json:"itemCount"`
+		UniqueThreatNameCount int          `json:"uniqueThreatNameCount"`
+		Shortened             bool         `json:"shortened"`
+		ThreatNames           []ThreatName `json:"threatNames,omitempty"`
+	} `json:"threatDetectedByName"`
+}
+
+type ThreatName struct {
+	Name      string       `json:"name"`
+	Severity  string       `json:"severity"`
+	ItemCount int          `json:"itemCount"`
+	FilePaths []ThreatFile `json:"filePaths,omitempty"`
+}
+
+type ThreatFile struct {
+	FilePath  string `json:"filePath"`
+	FileName  string `json:"fileName"`
+	VolumeArn string `json:"volumeArn"`
+	Hash      string `json:"hash"`
+}
@@ -0,0 +1,84 @@
+// runtime.go
+//
+// runtime monitoring — process and container context attached to findings
+// from the guardduty runtime agent on eks, ecs and ec2.
+
+package finding
+
+type RuntimeDetails struct {
+	Process *ProcessDetails `json:"process,omitempty"`
+	Context *RuntimeContext `json:"context,omitempty"`
+}
+
+type ProcessDetails struct {
+	Name           string           `json:"name"`
+	ExecutablePath string           `json:"executablePath"`
+	Pid            int              `json:"pid"`
+	Pwd            string           `json:"pwd"`
+	User           string           `json:"user"`
+	Euid           int              `json:"euid"`
+	Lineage        []ProcessLineage `json:"lineage,omitempty"`
+}
+
+// ProcessLineage is one ancestor of the process, ordered from the direct
+// parent upwards.
+type ProcessLineage struct {
+	Name           string `json:"name"`
+	ExecutablePath string `json:"executablePath"`
+	Pid            int    `json:"pid"`
+	Euid           int    `json:"euid"`
+}
+
+// RuntimeContext carries the type-specific evidence. only the commonly
+// populated fields are modeled; the rest is in the raw finding.
+type RuntimeContext struct {
+	ScriptPath         string `json:"scriptPath,omitempty"`
+	ThreatFilePath     string `json:"threatFilePath,omitempty"`
+	CommandLineExample string `json:"commandLineExample,omitempty"`
+	ToolName           string `json:"toolName,omitempty"`
+	ToolCategory       string `json:"toolCategory,omitempty"`
+}
+
+type KubernetesDetails struct {
+	KubernetesUserDetails     *KubernetesUser     `json:"kubernetesUserDetails,omitempty"`
+	KubernetesWorkloadDetails *KubernetesWorkload `json:"kubernetesWorkloadDetails,omitempty"`
+}
+
+type KubernetesUser struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+type KubernetesWorkload struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Namespace   string      `json:"namespace"`
+	HostNetwork bool        `json:"hostNetwork"`
+	Containers  []Container `json:"containers,omitempty"`
+}
+
+type Container struct {
+	Name            string `json:"name"`
+	ID              string `json:"id"`
+	Image           string `json:"image"`
+	SecurityContext *struct {
+		Privileged bool `json:"privileged"`
+	} `json:"securityContext,omitempty"`
+}
+
+type EksClusterDetails struct {
+	Name  string `json:"name"`
+	Arn   string `json:"arn"`
+	VpcID string `json:"vpcId"`
+}
+
+type EcsClusterDetails struct {
+	Name        string `json:"name"`
+	Arn         string `json:"arn"`
+	TaskDetails *struct {
+		Arn           string      `json:"arn"`
+		DefinitionArn string      `json:"definitionArn"`
+		LaunchType    string      `json:"launchType"`
+		Containers    []Container `json:"containers,omitempty"`
+	} `json:"taskDetails,omitempty"`
+}
@@ -0,0 +1,147 @@
+// severity.go
+//
+// severity thresholds — map numeric scores to labels. labels may be the
+// built-in levels or org-specific names such as P1–P4; each bucket also has a
+// built-in level that drives colors, escalation priority and metrics.
+
+package finding
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Threshold is one severity bucket: scores below Max (or up to it, when
+// Inclusive) that did not fall into a lower bucket.
+type Threshold struct {
+	Label     string
+	Level     SeverityLevel
+	Max       float64
+	Inclusive bool
+}
+
+// Thresholds are ordered from the lowest bucket to the highest.
+type Thresholds []Threshold
+
+// DefaultThresholds is guardduty's own classification.
+var DefaultThresholds = Thresholds{
+	{Label: "low", Level: SeverityLow, Max: 4},
+	{Label: "medium", Level: SeverityMedium, Max: 7},
+	{Label: "high", Level: SeverityHigh, Max: 9},
+	{Label: "critical", Level: SeverityCritical, Max: 10, Inclusive: true},
+}
+
+var levelsByRank = []SeverityLevel{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// ParseThresholds reads "low<4,medium<7,high<8.5,critical<=10". buckets must
+// be listed in ascending order and the highest must reach 10, so no finding
+// falls outside them. a label that is not a built-in level gets one by rank,
+// so the highest bucket is always critical.
+func ParseThresholds(s string) (Thresholds, error) {
+	var (
+		parts = strings.Split(s, ",")
+		t     = make(Thresholds, 0, len(parts))
+		err   error
+	)
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		label, bound, ok := strings.Cut(part, "<")
+		if !ok || strings.TrimSpace(label) == "" {
+			return nil, fmt.Errorf("bucket %q: want <label><<max> or <label><=<max>", part)
+		}
+		th := Threshold{Label: strings.TrimSpace(label)}
+		if rest, ok := strings.CutPrefix(bound, "="); ok {
+			bound, th.Inclusive = rest, true
+		}
+		if th.Max, err = strconv.ParseFloat(strings.TrimSpace(bound), 64); err != nil {
+			return nil, fmt.Errorf("bucket %q: invalid bound: %w", part, err)
+		}
+		if i > 0 && th.Max <= t[i-1].Max {
+			return nil, fmt.Errorf("bucket %q: bounds must increase", part)
+		}
+		if _, dup := t.find(th.Label); dup {
+			return nil, fmt.Errorf("bucket %q: duplicate label", part)
+		}
+
+		th.Level = SeverityLevel(strings.ToLower(th.Label))
+		if !slices.Contains(levelsByRank, th.Level) {
+			// ceil so the top bucket lands on critical for any bucket count.
+			rank := ((i+1)*len(levelsByRank)+len(parts)-1)/len(parts) - 1
+			th.Level = levelsByRank[rank]
+		}
+		t = append(t, th)
+	}
+	if top := t[len(t)-1]; top.Max < 10 || top.Max == 10 && !top.Inclusive {
+		return nil, fmt.Errorf("bucket %q: the highest bucket must cover scores up to 10, e.g. %s<=10", top.Label, top.Label)
+	}
+	return t, nil
+}
+
+// Classify returns the level and label of score. scores above the top bucket
+// are unknown.
+func (t Thresholds) Classify(score float64) (SeverityLevel, string) {
+	for _, th := range t {
+		if score < th.Max || th.Inclusive && score == th.Max {
+			return th.Level, th.Label
+		}
+	}
+	return SeverityUnknown, string(SeverityUnknown)
+}
+
+// Has reports whether name is a label or built-in level of t.
+func (t Thresholds) Has(name string) bool {
+	_, ok := t.index(name)
+	return ok
+}
+
+// AtLeast reports whether score falls in the bucket of name or a higher one.
+// scores above the top bucket count as the highest.
+func (t Thresholds) AtLeast(score float64, name string) bool {
+	i, ok := t.index(name)
+	if !ok {
+		return false
+	}
+	// below name exactly when a lower bucket takes it.
+	for _, th := range t[:i] {
+		if score < th.Max || th.Inclusive && score == th.Max {
+			return false
+		}
+	}
+	return true
+}
+
+// Floor returns the lower bound of the bucket of name, for building range
+// filters. exclusive reports that a score equal to it still belongs to the
+// bucket below.
+func (t Thresholds) Floor(name string) (floor float64, exclusive, ok bool) {
+	i, ok := t.index(name)
+	if !ok || i == 0 {
+		return 0, false, ok
+	}
+	return t[i-1].Max, t[i-1].Inclusive, true
+}
+
+// index finds the bucket of name, a label or a built-in level. matching is
+// case-insensitive and labels win over levels.
+func (t Thresholds) index(name string) (int, bool) {
+	if i, ok := t.find(name); ok {
+		return i, true
+	}
+	for i, th := range t {
+		if strings.EqualFold(string(th.Level), name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (t Thresholds) find(label string) (int, bool) {
+	for i, th := range t {
+		if strings.EqualFold(th.Label, label) {
+			return i, true
+		}
+	}
+	return 0, false
+}
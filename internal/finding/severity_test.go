@@ -0,0 +1,99 @@
+package finding
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseThresholds(t *testing.T) {
+	tests := []struct {
+		spec    string
+		levels  []SeverityLevel
+		wantErr bool
+	}{
+		{spec: "low<4,medium<7,high<9,critical<=10", levels: []SeverityLevel{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}},
+		{spec: "P4<4,P3<7,P2<8,P1<=10", levels: []SeverityLevel{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}},
+		{spec: "minor<5,major<=10", levels: []SeverityLevel{SeverityMedium, SeverityCritical}},
+		{spec: "s3<3,s2<6,s1<=10", levels: []SeverityLevel{SeverityMedium, SeverityHigh, SeverityCritical}},
+		{spec: "a<2,b<4,c<6,d<8,e<=10", levels: []SeverityLevel{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical, SeverityCritical}},
+		// built-in names keep their level whatever their rank.
+		{spec: "info<4,High<=10", levels: []SeverityLevel{SeverityMedium, SeverityHigh}},
+		{spec: "low<7,high<4", wantErr: true},
+		{spec: "low<4,LOW<=10", wantErr: true},
+		{spec: "low=4", wantErr: true},
+		{spec: "low<four", wantErr: true},
+		{spec: "<4", wantErr: true},
+		// the most severe findings would classify as unknown.
+		{spec: "low<4,high<8", wantErr: true},
+		{spec: "low<4,high<10", wantErr: true},
+		{spec: "low<4,high<10.5", levels: []SeverityLevel{SeverityLow, SeverityHigh}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			th, err := ParseThresholds(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			var levels []SeverityLevel
+			for _, b := range th {
+				levels = append(levels, b.Level)
+			}
+			if !slices.Equal(levels, tt.levels) {
+				t.Errorf("levels = %v, want %v", levels, tt.levels)
+			}
+		})
+	}
+}
+
+func TestThresholdsEdges(t *testing.T) {
+	th, err := ParseThresholds("P3<=4,P2<7,P1<=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		score float64
+		label string
+		// atLeastP2 is whether the score meets a P2 threshold.
+		atLeastP2 bool
+	}{
+		{score: 0, label: "P3"},
+		{score: 4, label: "P3"},
+		{score: 4.1, label: "P2", atLeastP2: true},
+		{score: 6.9, label: "P2", atLeastP2: true},
+		{score: 7, label: "P1", atLeastP2: true},
+		{score: 10, label: "P1", atLeastP2: true},
+		{score: 10.5, label: string(SeverityUnknown), atLeastP2: true},
+	}
+	for _, tt := range tests {
+		if _, label := th.Classify(tt.score); label != tt.label {
+			t.Errorf("Classify(%v) = %s, want %s", tt.score, label, tt.label)
+		}
+		if got := th.AtLeast(tt.score, "p2"); got != tt.atLeastP2 {
+			t.Errorf("AtLeast(%v, p2) = %v, want %v", tt.score, got, tt.atLeastP2)
+		}
+	}
+
+	floors := []struct {
+		name      string
+		floor     float64
+		exclusive bool
+		ok        bool
+	}{
+		{name: "P3", floor: 0, ok: true},
+		{name: "P2", floor: 4, exclusive: true, ok: true},
+		{name: "p1", floor: 7, ok: true},
+		// built-in levels resolve to the bucket carrying them.
+		{name: "critical", floor: 7, ok: true},
+		{name: "P0"},
+	}
+	for _, tt := range floors {
+		floor, exclusive, ok := th.Floor(tt.name)
+		if floor != tt.floor || exclusive != tt.exclusive || ok != tt.ok {
+			t.Errorf("Floor(%s) = %v, %v, %v; want %v, %v, %v", tt.name, floor, exclusive, ok, tt.floor, tt.exclusive, tt.ok)
+		}
+	}
+	if th.AtLeast(10, "P0") {
+		t.Error("AtLeast with an unknown label should be false")
+	}
+}
@@ -0,0 +1,136 @@
+// threatintel.go
+//
+// threat intel — remote ip geo and network owner from service.action, plus
+// the threat lists that flagged the finding.
+
+package finding
+
+import "encoding/json"
+
+type RemoteIPDetails struct {
+	IPAddressV4 string `json:"ipAddressV4"`
+	IPAddressV6 string `json:"ipAddressV6,omitempty"`
+	Country     struct {
+		CountryName string `json:"countryName"`
+	} `json:"country"`
+	City struct {
+		CityName string `json:"cityName"`
+	} `json:"city"`
+	Organization struct {
+		Asn    string `json:"asn"`
+		AsnOrg string `json:"asnOrg"`
+		Isp    string `json:"isp"`
+		Org    string `json:"org"`
+	} `json:"organization"`
+}
+
+// IP returns the v4 address, falling back to v6.
+func (r RemoteIPDetails) IP() string {
+	if r.IPAddressV4 != "" {
+		return r.IPAddressV4
+	}
+	return r.IPAddressV6
+}
+
+type ThreatIntelligenceDetail struct {
+	ThreatListName string   `json:"threatListName"`
+	ThreatNames    []string `json:"threatNames,omitempty"`
+}
+
+type Evidence struct {
+	ThreatIntelligenceDetails []ThreatIntelligenceDetail `json:"threatIntelligenceDetails,omitempty"`
+}
+
+// the action variants that carry a remote peer.
+type networkActions struct {
+	NetworkConnectionAction *struct {
+		RemoteIPDetails RemoteIPDetails `json:"remoteIpDetails"`
+	} `json:"networkConnectionAction"`
+	AwsAPICallAction *struct {
+		RemoteIPDetails RemoteIPDetails `json:"remoteIpDetails"`
+	} `json:"awsApiCallAction"`
+	KubernetesAPICallAction *struct {
+		RemoteIPDetails RemoteIPDetails `json:"remoteIpDetails"`
+	} `json:"kubernetesApiCallAction"`
+	RdsLoginAttemptAction *struct {
+		RemoteIPDetails RemoteIPDetails `json:"remoteIpDetails"`
+	} `json:"rdsLoginAttemptAction"`
+	PortProbeAction *struct {
+		PortProbeDetails []struct {
+			RemoteIPDetails RemoteIPDetails `json:"remoteIpDetails"`
+		} `json:"portProbeDetails"`
+	} `json:"portProbeAction"`
+	DNSRequestAction *struct {
+		Domain string `json:"domain"`
+	} `json:"dnsRequestAction"`
+}
+
+func (f Finding) networkActions() networkActions {
+	var a networkActions
+	if len(f.Service.Action) > 0 {
+		json.Unmarshal(f.Service.Action, &a)
+	}
+	return a
+}
+
+// RemoteIPs lists the distinct remote peers in service.action, in the order
+// they appear.
+func (f Finding) RemoteIPs() []RemoteIPDetails {
+	a := f.networkActions()
+	var all []RemoteIPDetails
+	switch {
+	case a.NetworkConnectionAction != nil:
+		all = append(all, a.NetworkConnectionAction.RemoteIPDetails)
+	case a.AwsAPICallAction != nil:
+		all = append(all, a.AwsAPICallAction.RemoteIPDetails)
+	case a.KubernetesAPICallAction != nil:
+		all = append(all, a.KubernetesAPICallAction.RemoteIPDetails)
+	case a.RdsLoginAttemptAction != nil:
+		all = append(all, a.RdsLoginAttemptAction.RemoteIPDetails)
+	case a.PortProbeAction != nil:
+		for _, p := range a.PortProbeAction.PortProbeDetails {
+			all = append(all, p.RemoteIPDetails)
+		}
+	}
+
+	seen := map[string]bool{}
+	var out []RemoteIPDetails
+	for _, r := range all {
+		if ip := r.IP(); ip != "" && !seen[ip] {
+			seen[ip] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Domain returns the queried domain of a dns request finding.
+func (f Finding) Domain() string {
+	if a := f.networkActions(); a.DNSRequestAction != nil {
+		return a.DNSRequestAction.Domain
+	}
+	return ""
+}
+
+// ThreatLists returns the threat intelligence sets that matched.
+func (f Finding) ThreatLists() []ThreatIntelligenceDetail {
+	var lists []ThreatIntelligenceDetail
+	if f.Service.Evidence != nil {
+		lists = append(lists, f.Service.Evidence.ThreatIntelligenceDetails...)
+	}
+	if len(lists) == 0 && len(f.Service.AdditionalInfo) > 0 {
+		var info struct {
+			ThreatListName string `json:"threatListName"`
+			ThreatName     string `json:"threatName"`
+		}
+		json.Unmarshal(f.Service.AdditionalInfo, &info)
+		if info.ThreatListName != "" {
+			d := ThreatIntelligenceDetail{ThreatListName: info.ThreatListName}
+			if info.ThreatName != "" {
+				d.ThreatNames = []string{info.ThreatName}
+			}
+			lists = append(lists, d)
+		}
+	}
+	return lists
+}
@@ -0,0 +1,146 @@
+// batch.go
+//
+// batch invocations — eventbridge pipes, sqs and custom wrappers deliver several
+// events per invocation. each event is processed independently with bounded
+// parallelism and failures are reported per item.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	guarddutyslack "github.com/cruxstack/aws-guardduty-slack-integration-go"
+)
+
+// BatchResponse follows the partial batch failure contract understood by
+// eventbridge pipes and sqs event source mappings.
+type BatchResponse struct {
+	BatchItemFailures []BatchItemFailure `json:"batchItemFailures"`
+}
+
+type BatchItemFailure struct {
+	ItemIdentifier string `json:"itemIdentifier"`
+}
+
+type BatchItem struct {
+	ID    string
+	Event events.CloudWatchEvent
+	// Err is set when the item could not be decoded; it is reported as failed
+	// without holding back the rest of the batch.
+	Err error
+}
+
+// DecodeInvocation unpacks a lambda payload into events. it accepts a single
+// eventbridge event, an array of events, an {"events": [...]} wrapper, and
+// sqs records, bare or in an sqs event's {"Records": [...]}, whose body holds
+// the event. batch reports whether the
+// payload was a batch, which decides the response shape. a malformed item of
+// a batch is returned with Err set rather than failing the whole payload.
+func DecodeInvocation(payload json.RawMessage) (items []BatchItem, batch bool, err error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(payload, &raws); err == nil {
+		batch = true
+	} else {
+		var wrapper struct {
+			Events  []json.RawMessage `json:"events"`
+			Records []json.RawMessage `json:"Records"`
+		}
+		if err := json.Unmarshal(payload, &wrapper); err != nil {
+			return nil, false, fmt.Errorf("decode invocation: %w", err)
+		}
+		switch {
+		case wrapper.Events != nil:
+			raws, batch = wrapper.Events, true
+		case wrapper.Records != nil:
+			// an sqs event source; failures are reported by message id.
+			raws, batch = wrapper.Records, true
+		default:
+			raws = []json.RawMessage{payload}
+		}
+	}
+
+	for i, raw := range raws {
+		item, err := decodeBatchItem(raw)
+		if err != nil {
+			if !batch {
+				return nil, false, fmt.Errorf("decode event: %w", err)
+			}
+			item.Err = fmt.Errorf("decode item %d: %w", i, err)
+		}
+		if item.ID == "" {
+			item.ID = strconv.Itoa(i)
+		}
+		items = append(items, item)
+	}
+	return items, batch, nil
+}
+
+func decodeBatchItem(raw json.RawMessage) (BatchItem, error) {
+	var record struct {
+		MessageID string `json:"messageId"`
+		Body      string `json:"body"`
+	}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return BatchItem{}, err
+	}
+
+	id := record.MessageID
+	if record.Body != "" {
+		raw = json.RawMessage(record.Body)
+	}
+
+	var evt events.CloudWatchEvent
+	if err := json.Unmarshal(raw, &evt); err != nil {
+		// keep the message id so the right record is retried.
+		return BatchItem{ID: id}, err
+	}
+	if id == "" {
+		id = evt.ID
+	}
+	return BatchItem{ID: id, Event: evt}, nil
+}
+
+// ProcessBatch handles every item, at most cfg.MaxConcurrency at a time. a
+// failing item never stops the others.
+func ProcessBatch(ctx context.Context, app *guarddutyslack.App, items []BatchItem) BatchResponse {
+	var (
+		evts    []events.CloudWatchEvent
+		decoded []BatchItem
+	)
+	resp := BatchResponse{BatchItemFailures: []BatchItemFailure{}}
+	var errs []error
+	fail := func(item BatchItem, err error) {
+		app.Logger().Error("batch item failed", "item_id", item.ID, "error", err)
+		resp.BatchItemFailures = append(resp.BatchItemFailures, BatchItemFailure{ItemIdentifier: item.ID})
+		errs = append(errs, fmt.Errorf("item %s: %w", item.ID, err))
+	}
+	for _, item := range items {
+		if item.Err != nil {
+			fail(item, item.Err)
+			continue
+		}
+		evts = append(evts, item.Event)
+		decoded = append(decoded, item)
+	}
+
+	for i, err := range app.HandleEvents(ctx, evts) {
+		if err != nil {
+			fail(decoded[i], err)
+		}
+	}
+
+	if len(errs) > 0 {
+		app.Logger().Warn("batch completed with failures",
+			"items", len(items),
+			"failed", len(errs),
+			"error", errors.Join(errs...),
+		)
+	}
+	return resp
+}
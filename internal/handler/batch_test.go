@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeInvocationMalformedItem(t *testing.T) {
+	payload := json.RawMessage(`{"events": [
+		{"id": "evt-1", "detail-type": "GuardDuty Finding", "detail": {}},
+		{"messageId": "msg-2", "body": "not json"},
+		{"id": "evt-3", "detail-type": "GuardDuty Finding", "detail": {}}
+	]}`)
+	items, batch, err := DecodeInvocation(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !batch || len(items) != 3 {
+		t.Fatalf("got batch %v with %d items, want a batch of 3", batch, len(items))
+	}
+	for i, want := range []string{"evt-1", "msg-2", "evt-3"} {
+		if items[i].ID != want {
+			t.Errorf("item %d: id %q, want %q", i, items[i].ID, want)
+		}
+		if bad := items[i].Err != nil; bad != (i == 1) {
+			t.Errorf("item %d: err %v", i, items[i].Err)
+		}
+	}
+
+	if _, _, err := DecodeInvocation(json.RawMessage(`"not an event"`)); err == nil {
+		t.Error("a malformed single event should fail the invocation")
+	}
+}
+
+func TestDecodeInvocationSQSEvent(t *testing.T) {
+	payload := json.RawMessage(`{"Records": [
+		{"messageId": "msg-1", "eventSource": "aws:sqs", "body": "{\"id\": \"evt-1\", \"detail-type\": \"GuardDuty Finding\", \"detail\": {\"id\": \"f-1\"}}"},
+		{"messageId": "msg-2", "eventSource": "aws:sqs", "body": "not json"}
+	]}`)
+	items, batch, err := DecodeInvocation(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !batch || len(items) != 2 {
+		t.Fatalf("got batch %v with %d items, want a batch of 2", batch, len(items))
+	}
+	if items[0].ID != "msg-1" || items[0].Err != nil || items[0].Event.ID != "evt-1" || len(items[0].Event.Detail) == 0 {
+		t.Errorf("item 0: got %+v, want the event from the body under msg-1", items[0])
+	}
+	if items[1].ID != "msg-2" || items[1].Err == nil {
+		t.Errorf("item 1: got %+v, want a failure reported as msg-2", items[1])
+	}
+}
@@ -0,0 +1,103 @@
+// handler.go
+//
+// lambda handler — lazily builds the app on the first invocation and routes
+// function url requests, single events and batches.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	guarddutyslack "github.com/cruxstack/aws-guardduty-slack-integration-go"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/ack"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/slashcmd"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/tracing"
+)
+
+type Handler struct {
+	cfgOnce sync.Once
+	cfg     guarddutyslack.Config
+	cfgErr  error
+
+	// mu guards the app, which is retried until it builds and passes the
+	// startup check, and the slash commander and acker, retried until they
+	// build.
+	mu      sync.Mutex
+	app     *guarddutyslack.App
+	tracing *tracing.Provider
+	checked bool
+
+	commander *slashcmd.Commander
+	ackerInst *ack.Acker
+}
+
+func New() *Handler {
+	return &Handler{}
+}
+
+func (h *Handler) Invoke(ctx context.Context, payload json.RawMessage) (any, error) {
+	if err := h.init(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx = tracing.FromLambda(ctx)
+	// the process is frozen once the invocation returns, so export now.
+	defer func() {
+		if err := h.tracing.Flush(ctx); err != nil {
+			h.app.Logger().Warn("failed to flush traces", "error", err)
+		}
+	}()
+
+	if req, ok := decodeHTTPRequest(payload); ok {
+		return h.serveHTTP(ctx, req)
+	}
+
+	items, batch, err := DecodeInvocation(payload)
+	if err != nil {
+		return nil, err
+	}
+	if !batch {
+		return nil, h.app.HandleEvent(ctx, items[0].Event)
+	}
+	h.app.Logger().Info("batch received", "items", len(items))
+	return ProcessBatch(ctx, h.app, items), nil
+}
+
+// init builds the app on the first invocation. a config error is permanent,
+// but anything talking to aws or slack may be transient, so a failed build or
+// startup check is tried again on the next invocation.
+func (h *Handler) init(ctx context.Context) error {
+	h.cfgOnce.Do(func() {
+		h.cfg, h.cfgErr = guarddutyslack.BuildConfig()
+	})
+	if h.cfgErr != nil {
+		return h.cfgErr
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cfg.TracingEnabled && h.tracing == nil {
+		var err error
+		if h.tracing, err = tracing.Setup(ctx); err != nil {
+			return err
+		}
+	}
+	if h.app == nil {
+		app, err := guarddutyslack.NewApp(ctx, h.cfg)
+		if err != nil {
+			return err
+		}
+		h.app = app
+	}
+	// fail on a bad token or unknown channel rather than on every finding.
+	if h.cfg.StartupCheck && !h.checked {
+		if err := h.app.Check(ctx); err != nil {
+			return fmt.Errorf("startup check: %w", err)
+		}
+		h.checked = true
+	}
+	return nil
+}
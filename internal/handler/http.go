@@ -0,0 +1,158 @@
+// http.go
+//
+// function url requests — slack slash commands and interactions arrive as
+// signed form posts. every request is verified against
+// APP_SLACK_SIGNING_SECRET before use.
+
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-lambda-go/events"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/slashcmd"
+)
+
+// decodeHTTPRequest reports whether payload is a lambda function url request.
+func decodeHTTPRequest(payload json.RawMessage) (events.LambdaFunctionURLRequest, bool) {
+	var req events.LambdaFunctionURLRequest
+	if err := json.Unmarshal(payload, &req); err != nil || req.RequestContext.HTTP.Method == "" {
+		return events.LambdaFunctionURLRequest{}, false
+	}
+	return req, true
+}
+
+func textResponse(status int, body string) events.LambdaFunctionURLResponse {
+	return events.LambdaFunctionURLResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "text/plain"},
+		Body:       body,
+	}
+}
+
+func jsonResponse(v any) (events.LambdaFunctionURLResponse, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return events.LambdaFunctionURLResponse{}, err
+	}
+	return events.LambdaFunctionURLResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}, nil
+}
+
+// verifyRequest checks the slack signature and returns the decoded body.
+func verifyRequest(req events.LambdaFunctionURLRequest, secret string) ([]byte, error) {
+	body := []byte(req.Body)
+	if req.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(req.Body); err != nil {
+			return nil, fmt.Errorf("decode body: %w", err)
+		}
+	}
+
+	header := http.Header{}
+	for k, v := range req.Headers {
+		header.Set(k, v)
+	}
+	sv, err := slack.NewSecretsVerifier(header, secret)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sv.Write(body); err != nil {
+		return nil, err
+	}
+	if err := sv.Ensure(); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (h *Handler) serveHTTP(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	cfg := h.app.Config()
+	log := h.app.Logger()
+	if cfg.SlackSigningSecret == "" {
+		return textResponse(http.StatusNotFound, "not found"), nil
+	}
+
+	body, err := verifyRequest(req, cfg.SlackSigningSecret)
+	if err != nil {
+		log.Warn("rejected unsigned or invalid slack request", "error", err)
+		return textResponse(http.StatusUnauthorized, "invalid signature"), nil
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return textResponse(http.StatusBadRequest, "invalid form body"), nil
+	}
+
+	if payload := form.Get("payload"); payload != "" {
+		return h.serveInteraction(ctx, payload)
+	}
+	if form.Get("command") == "" {
+		return textResponse(http.StatusBadRequest, "unsupported request"), nil
+	}
+	cmd := slack.SlashCommand{
+		TeamID:      form.Get("team_id"),
+		ChannelID:   form.Get("channel_id"),
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		Command:     form.Get("command"),
+		Text:        form.Get("text"),
+		ResponseURL: form.Get("response_url"),
+	}
+	log.Info("slash command received", "command", cmd.Command, "user_id", cmd.UserID, "text", cmd.Text)
+
+	commander, err := h.slashCommander(ctx)
+	if err != nil {
+		log.Error("failed to initialize slash commands", "error", err)
+		return jsonResponse(slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: "guardduty query is unavailable, see the function logs"})
+	}
+	msg, err := commander.Run(ctx, cmd)
+	if err != nil {
+		log.Error("slash command failed", "error", err)
+		msg = slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: "guardduty query failed, see the function logs"}
+	}
+	return jsonResponse(msg)
+}
+
+// slashCommander builds the commander on first use. a failed aws call may be
+// transient, so it is tried again on the next command.
+func (h *Handler) slashCommander(ctx context.Context) (*slashcmd.Commander, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.commander != nil {
+		return h.commander, nil
+	}
+
+	cfg := h.app.Config()
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	client := guardduty.NewFromConfig(awsCfg)
+
+	detectorID := cfg.GuardDutyDetectorID
+	if detectorID == "" {
+		out, err := client.ListDetectors(ctx, &guardduty.ListDetectorsInput{})
+		if err != nil {
+			return nil, fmt.Errorf("list detectors: %w", err)
+		}
+		if len(out.DetectorIds) == 0 {
+			return nil, errors.New("no guardduty detector found; set APP_GUARDDUTY_DETECTOR_ID")
+		}
+		detectorID = out.DetectorIds[0]
+	}
+	h.commander = slashcmd.NewCommander(client, detectorID, cfg.AwsConsoleURL, cfg.SeverityThresholds)
+	return h.commander, nil
+}
@@ -0,0 +1,82 @@
+// interact.go
+//
+// slack interactivity — button clicks arrive at the function url as a signed
+// form post with a json "payload" field.
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/ack"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/slackout"
+)
+
+func (h *Handler) serveInteraction(ctx context.Context, payload string) (events.LambdaFunctionURLResponse, error) {
+	log := h.app.Logger()
+
+	var cb slack.InteractionCallback
+	if err := json.Unmarshal([]byte(payload), &cb); err != nil {
+		return textResponse(http.StatusBadRequest, "invalid payload"), nil
+	}
+	if cb.Type != slack.InteractionTypeBlockActions || !h.app.Config().AckEnabled {
+		// link buttons also send interactions; slack only needs a 200.
+		return textResponse(http.StatusOK, ""), nil
+	}
+
+	acker, err := h.acker(ctx)
+	if err != nil {
+		log.Error("failed to initialize acknowledgments", "error", err)
+		return textResponse(http.StatusOK, ""), nil
+	}
+	if err := acker.Handle(ctx, cb); err != nil {
+		log.Error("failed to update finding status", "user_id", cb.User.ID, "error", err)
+	}
+	return textResponse(http.StatusOK, ""), nil
+}
+
+// acker builds the acker on first use. a failed build is tried again on the
+// next click rather than disabling the buttons until the next cold start.
+func (h *Handler) acker(ctx context.Context) (*ack.Acker, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.ackerInst != nil {
+		return h.ackerInst, nil
+	}
+
+	cfg := h.app.Config()
+	httpClient, err := slackout.NewHTTPClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AckTable == "" && !cfg.AckFeedback {
+		h.ackerInst = ack.NewAcker(h.app.Logger(), nil, nil, h.app.StatusObserver(), "", httpClient)
+		return h.ackerInst, nil
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	var (
+		store    ack.Store
+		feedback ack.FeedbackAPI
+	)
+	if cfg.AckTable != "" {
+		store = ack.NewDynamoDB(dynamodb.NewFromConfig(awsCfg), cfg.AckTable)
+	}
+	if cfg.AckFeedback {
+		feedback = guardduty.NewFromConfig(awsCfg)
+	}
+	h.ackerInst = ack.NewAcker(h.app.Logger(), store, feedback, h.app.StatusObserver(), cfg.GuardDutyDetectorID, httpClient)
+	return h.ackerInst, nil
+}
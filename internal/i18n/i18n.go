@@ -0,0 +1,65 @@
+// i18n.go
+//
+// message catalog — translates the labels around a finding (field names,
+// buttons, severity words) into APP_LOCALE. finding content is never
+// translated. keys are the english text, so english needs no catalog.
+
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultLocale is used when APP_LOCALE is not set.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Catalog maps english text to its translation. the zero value is english.
+type Catalog map[string]string
+
+// Load returns the catalog of locale, e.g. "ja" or "de".
+func Load(locale string) (Catalog, error) {
+	if locale == "" || locale == DefaultLocale {
+		return nil, nil
+	}
+	data, err := locales.ReadFile(path.Join("locales", locale+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported locale %q (available: %s)", locale, strings.Join(Locales(), ", "))
+	}
+	var c Catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse locale %s: %w", locale, err)
+	}
+	return c, nil
+}
+
+// Locales lists the supported locales.
+func Locales() []string {
+	out := []string{DefaultLocale}
+	entries, _ := locales.ReadDir("locales")
+	for _, e := range entries {
+		out = append(out, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	slices.Sort(out)
+	return out
+}
+
+// T translates key, falling back to key itself, and formats it with args
+// when given.
+func (c Catalog) T(key string, args ...any) string {
+	msg, ok := c[key]
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
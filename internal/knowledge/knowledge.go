@@ -0,0 +1,111 @@
+// knowledge.go
+//
+// knowledge base — map guardduty finding types to the aws documentation and
+// optional org-specific runbooks.
+
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/config"
+)
+
+const guardDutyDocsBase = "https://docs.aws.amazon.com/guardduty/latest/ug/"
+
+// docs pages keyed by the resource segment of the finding type
+// (ThreatPurpose:ResourceTypeAffected/ThreatFamilyName).
+var findingDocsPages = map[string]string{
+	"EC2":        "guardduty_finding-types-ec2.html",
+	"IAMUser":    "guardduty_finding-types-iam.html",
+	"S3":         "guardduty_finding-types-s3.html",
+	"Kubernetes": "guardduty_finding-types-kubernetes.html",
+	"Runtime":    "findings-runtime-monitoring.html",
+	"RDS":        "findings-rds-protection.html",
+	"Lambda":     "lambda-protection-finding-types.html",
+}
+
+// DocsURL returns the aws documentation anchor for a finding type, or
+// the finding types overview when the resource segment is not recognized.
+func DocsURL(findingType string) string {
+	_, rest, ok := strings.Cut(findingType, ":")
+	if !ok {
+		return guardDutyDocsBase + "guardduty_finding-types-active.html"
+	}
+	resource, _, _ := strings.Cut(rest, "/")
+
+	page, ok := findingDocsPages[resource]
+	if !ok {
+		return guardDutyDocsBase + "guardduty_finding-types-active.html"
+	}
+
+	// anchors are the lowercased type with separators collapsed, e.g.
+	// UnauthorizedAccess:IAMUser/TorIPCaller -> unauthorizedaccess-iam-toripcaller
+	anchor := strings.Replace(findingType, ":IAMUser/", ":IAM/", 1)
+	anchor = strings.NewReplacer(":", "-", "/", "-", ".", "", "!", "").Replace(anchor)
+	return guardDutyDocsBase + page + "#" + strings.ToLower(anchor)
+}
+
+// ------------------------------------------------------------- runbooks ---
+
+type Runbook struct {
+	Match string `json:"match"`
+	URL   string `json:"url"`
+	Label string `json:"label,omitempty"`
+	Hint  string `json:"hint,omitempty"`
+
+	re *regexp.Regexp
+}
+
+type Runbooks []Runbook
+
+// LoadRunbooks reads the runbook config from inline APP_RUNBOOKS json or the
+// APP_RUNBOOKS_PATH file or s3 object.
+func LoadRunbooks(ctx context.Context, cfg config.Config) (Runbooks, error) {
+	raw := cfg.Runbooks
+	if raw == "" && cfg.RunbooksPath != "" {
+		var err error
+		if raw, err = config.ReadSource(ctx, cfg.RunbooksPath); err != nil {
+			return nil, err
+		}
+	}
+	if raw == "" {
+		return nil, nil
+	}
+
+	var rbs Runbooks
+	if err := json.Unmarshal([]byte(raw), &rbs); err != nil {
+		return nil, fmt.Errorf("parse runbooks: %w", err)
+	}
+	for i := range rbs {
+		if rbs[i].Match == "" || rbs[i].URL == "" {
+			return nil, fmt.Errorf("runbook %d: match and url are required", i)
+		}
+		rbs[i].re = GlobRegexp(rbs[i].Match)
+	}
+	return rbs, nil
+}
+
+// Lookup returns the first runbook whose pattern matches the finding type.
+func (rbs Runbooks) Lookup(findingType string) (Runbook, bool) {
+	for _, rb := range rbs {
+		if rb.re.MatchString(findingType) {
+			return rb, true
+		}
+	}
+	return Runbook{}, false
+}
+
+// GlobRegexp compiles a glob where * matches any run of characters, including
+// the : and / separators used in finding types.
+func GlobRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
@@ -0,0 +1,116 @@
+// metrics.go
+//
+// cloudwatch metrics — emitted as embedded metric format (emf) log lines so
+// no putmetricdata permissions or extra api calls are needed.
+
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+const (
+	FindingsProcessed  = "FindingsProcessed"
+	FindingsSuppressed = "FindingsSuppressed"
+	FindingsDigested   = "FindingsDigested"
+	FindingsDuplicate  = "FindingsDuplicate"
+	FindingsEscalated  = "FindingsEscalated"
+	FindingsThrottled  = "FindingsThrottled"
+	EscalationFailures = "EscalationFailures"
+	SlackFailures      = "SlackFailures"
+	ArchiveFailures    = "ArchiveFailures"
+	ParseFailures      = "ParseFailures"
+	EventsParked       = "EventsParked"
+	ProcessingLatency  = "ProcessingLatencyMs"
+)
+
+const (
+	UnitCount        = "Count"
+	UnitMilliseconds = "Milliseconds"
+)
+
+type Metric struct {
+	Name  string
+	Unit  string
+	Value float64
+}
+
+func Count(name string) Metric {
+	return Metric{Name: name, Unit: UnitCount, Value: 1}
+}
+
+func Latency(name string, d time.Duration) Metric {
+	return Metric{Name: name, Unit: UnitMilliseconds, Value: float64(d.Milliseconds())}
+}
+
+type Metrics struct {
+	mu        sync.Mutex
+	w         io.Writer
+	namespace string
+	enabled   bool
+}
+
+func New(w io.Writer, namespace string, enabled bool) *Metrics {
+	return &Metrics{w: w, namespace: namespace, enabled: enabled}
+}
+
+// Put writes a single emf record holding every metric under one dimension
+// set. an empty dims map publishes the metrics without dimensions.
+func (m *Metrics) Put(dims map[string]string, metrics ...Metric) {
+	if m == nil || !m.enabled || len(metrics) == 0 {
+		return
+	}
+
+	type metricDef struct {
+		Name string `json:"Name"`
+		Unit string `json:"Unit"`
+	}
+	defs := make([]metricDef, 0, len(metrics))
+	record := map[string]any{}
+	for k, v := range dims {
+		record[k] = v
+	}
+	for _, mt := range metrics {
+		defs = append(defs, metricDef{Name: mt.Name, Unit: mt.Unit})
+		record[mt.Name] = mt.Value
+	}
+
+	dimKeys := slices.Sorted(maps.Keys(dims))
+	if dimKeys == nil {
+		dimKeys = []string{}
+	}
+	record["_aws"] = map[string]any{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  m.namespace,
+			"Dimensions": [][]string{dimKeys},
+			"Metrics":    defs,
+		}},
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.w.Write(data)
+}
+
+// FindingDimensions returns the severity and account dimensions shared by all
+// per-finding metrics.
+func FindingDimensions(f finding.Finding) map[string]string {
+	return map[string]string{
+		"Severity":  string(f.SeverityLabel),
+		"AccountId": f.AccountID,
+	}
+}
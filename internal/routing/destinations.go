@@ -0,0 +1,100 @@
+// destinations.go
+//
+// workspace destinations — named slack token + channel pairs selected by
+// account id or account tag, so one deployment can notify the prod workspace
+// for prod accounts and the dev workspace for sandbox accounts.
+
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/config"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/knowledge"
+)
+
+// Destination matches a finding when its account is listed or the account
+// carries every tag, values being globs. one without selectors matches all.
+type Destination struct {
+	Name string `json:"name"`
+	// Token is the workspace's bot token; empty means APP_SLACK_TOKEN.
+	Token    string            `json:"token,omitempty"`
+	Channel  string            `json:"channel"`
+	Accounts []string          `json:"accounts,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+
+	tags map[string]*regexp.Regexp
+}
+
+type Destinations []Destination
+
+// LoadDestinations reads the destinations from inline APP_DESTINATIONS json or
+// the APP_DESTINATIONS_PATH file or s3 object.
+func LoadDestinations(ctx context.Context, cfg config.Config) (Destinations, error) {
+	raw := cfg.Destinations
+	if raw == "" && cfg.DestinationsPath != "" {
+		var err error
+		if raw, err = config.ReadSource(ctx, cfg.DestinationsPath); err != nil {
+			return nil, err
+		}
+	}
+	if raw == "" {
+		return nil, nil
+	}
+
+	var dests Destinations
+	if err := json.Unmarshal([]byte(raw), &dests); err != nil {
+		return nil, fmt.Errorf("parse destinations: %w", err)
+	}
+	var names []string
+	for i := range dests {
+		d := &dests[i]
+		if d.Name == "" || d.Channel == "" {
+			return nil, fmt.Errorf("destination %d: name and channel are required", i)
+		}
+		if slices.Contains(names, d.Name) {
+			return nil, fmt.Errorf("destination %d: duplicate name %q", i, d.Name)
+		}
+		names = append(names, d.Name)
+		if !config.ValidChannelID(d.Channel) {
+			return nil, fmt.Errorf("destination %s: %q is not a channel id", d.Name, d.Channel)
+		}
+		d.tags = map[string]*regexp.Regexp{}
+		for k, v := range d.Tags {
+			d.tags[k] = knowledge.GlobRegexp(v)
+		}
+	}
+	return dests, nil
+}
+
+// Matches reports whether the finding's account selects d. destinations are
+// tried in order and the first match wins.
+func (d Destination) Matches(f finding.Finding) bool {
+	if len(d.Accounts) == 0 && len(d.tags) == 0 {
+		return true
+	}
+	if slices.Contains(d.Accounts, f.AccountID) {
+		return true
+	}
+	if len(d.tags) == 0 {
+		return false
+	}
+	for k, re := range d.tags {
+		v, ok := f.AccountTags[k]
+		if !ok || !re.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// UsesTags reports whether any destination selects by account tag, which
+// needs the account tags from organizations.
+func (dests Destinations) UsesTags() bool {
+	return slices.ContainsFunc(dests, func(d Destination) bool { return len(d.Tags) > 0 })
+}
@@ -0,0 +1,71 @@
+// routing.go
+//
+// finding type routing — send finding classes to the channels of the teams
+// that own them, e.g. CryptoCurrency:* to #soc-crypto.
+
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/config"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/knowledge"
+)
+
+type Rule struct {
+	Match   string `json:"match"`
+	Channel string `json:"channel"`
+
+	re *regexp.Regexp
+}
+
+type Rules []Rule
+
+// LoadRules reads the routing config from inline APP_ROUTES json or the
+// APP_ROUTES_PATH file or s3 object.
+func LoadRules(ctx context.Context, cfg config.Config) (Rules, error) {
+	raw := cfg.Routes
+	if raw == "" && cfg.RoutesPath != "" {
+		var err error
+		if raw, err = config.ReadSource(ctx, cfg.RoutesPath); err != nil {
+			return nil, err
+		}
+	}
+	if raw == "" {
+		return nil, nil
+	}
+
+	var rules Rules
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("parse routes: %w", err)
+	}
+	for i := range rules {
+		if rules[i].Match == "" || rules[i].Channel == "" {
+			return nil, fmt.Errorf("route %d: match and channel are required", i)
+		}
+		rules[i].re = knowledge.GlobRegexp(rules[i].Match)
+	}
+	return rules, nil
+}
+
+// Channel returns the channel of the first rule matching the finding type.
+func (rules Rules) Channel(findingType string) (string, bool) {
+	for _, r := range rules {
+		if r.re.MatchString(findingType) {
+			return r.Channel, true
+		}
+	}
+	return "", false
+}
+
+// Channels lists the channels the rules route to.
+func (rules Rules) Channels() []string {
+	out := make([]string, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, r.Channel)
+	}
+	return out
+}
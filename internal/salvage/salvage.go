@@ -0,0 +1,173 @@
+// salvage.go
+//
+// unparseable events — an event that is not a finding we understand is parked
+// instead of failed, so lambda does not retry a poison pill forever. it goes
+// either to slack as a minimal "unrecognized security event" message with the
+// raw payload in its thread, or to an sqs queue for later inspection.
+
+package salvage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/slackout"
+)
+
+// inlineLimit leaves room for the code fence within slack's 3000 character
+// text limit; larger payloads are uploaded as a file.
+const inlineLimit = 2900
+
+type Parker interface {
+	Park(ctx context.Context, evt events.CloudWatchEvent, cause error) error
+}
+
+// Marshal encodes evt. a detail that is not valid json is kept as a string so
+// the envelope still encodes.
+func Marshal(evt events.CloudWatchEvent) ([]byte, error) {
+	if !json.Valid(evt.Detail) {
+		detail, err := json.Marshal(string(evt.Detail))
+		if err != nil {
+			return nil, err
+		}
+		evt.Detail = detail
+	}
+	return json.Marshal(evt)
+}
+
+// Payload renders the event for humans. events handed to App.Process carry
+// only a detail, so the empty envelope is left out.
+func Payload(evt events.CloudWatchEvent) []byte {
+	raw, err := Marshal(evt)
+	if err != nil || evt.Source == "" && evt.ID == "" {
+		raw = evt.Detail
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
+
+// ----------------------------------------------------------------- slack ---
+
+type Slack struct {
+	log     *slog.Logger
+	poster  slackout.SlackPoster
+	channel string
+}
+
+func NewSlack(log *slog.Logger, poster slackout.SlackPoster, channel string) *Slack {
+	return &Slack{log: log, poster: poster, channel: channel}
+}
+
+func (s *Slack) Park(ctx context.Context, evt events.CloudWatchEvent, cause error) error {
+	ts, err := s.poster.PostMessage(ctx, s.channel, "", "Unrecognized security event", Render(evt, cause))
+	if err != nil {
+		return fmt.Errorf("post unrecognized event: %w", err)
+	}
+
+	body := string(Payload(evt))
+	if len(body) <= inlineLimit {
+		_, err = s.poster.PostMessage(ctx, s.channel, ts, "Raw event", []slack.Block{
+			slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", "```"+body+"```", false, false), nil, nil),
+		})
+	} else {
+		err = s.poster.UploadFile(ctx, s.channel, ts, fileName(evt.ID), body)
+	}
+	// the event is already on record in the channel; don't retry over a
+	// missing reply.
+	if err != nil {
+		s.log.Warn("failed to post raw event", "event_id", evt.ID, "error", err)
+	}
+	return nil
+}
+
+// Render builds the parent message: what little is known about the event and
+// why it could not be parsed.
+func Render(evt events.CloudWatchEvent, cause error) []slack.Block {
+	var fields []*slack.TextBlockObject
+	for _, kv := range [][2]string{
+		{"Source", evt.Source},
+		{"Detail Type", evt.DetailType},
+		{"Account", evt.AccountID},
+		{"Region", evt.Region},
+	} {
+		if kv[1] != "" {
+			fields = append(fields, slack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*%s:*\n%s", kv[0], kv[1]), false, false))
+		}
+	}
+
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn",
+			":grey_question: *Unrecognized security event*\nThe event could not be parsed as a finding; the raw payload is in the thread.",
+			false, false), nil, nil),
+	}
+	if len(fields) > 0 {
+		blocks = append(blocks, slack.NewSectionBlock(nil, fields, nil))
+	}
+	var notes []slack.MixedElement
+	if evt.ID != "" {
+		notes = append(notes, slack.NewTextBlockObject("mrkdwn", "Event ID: `"+evt.ID+"`", false, false))
+	}
+	if cause != nil {
+		notes = append(notes, slack.NewTextBlockObject("plain_text", slackout.Truncate("Error: "+cause.Error(), 300), false, false))
+	}
+	if len(notes) > 0 {
+		blocks = append(blocks, slack.NewContextBlock("", notes...))
+	}
+	return blocks
+}
+
+func fileName(eventID string) string {
+	if eventID == "" {
+		return "event.json"
+	}
+	return "event-" + strings.ReplaceAll(eventID, "/", "-") + ".json"
+}
+
+// ------------------------------------------------------------------- sqs ---
+
+type SQS struct {
+	client   *sqs.Client
+	queueURL string
+}
+
+func NewSQS(client *sqs.Client, queueURL string) *SQS {
+	return &SQS{client: client, queueURL: queueURL}
+}
+
+// Park sends the raw event with the parse error as a message attribute, so
+// the queue can be redriven once the parser understands it.
+func (s *SQS) Park(ctx context.Context, evt events.CloudWatchEvent, cause error) error {
+	body, err := Marshal(evt)
+	if err != nil {
+		return err
+	}
+	attrs := map[string]sqstypes.MessageAttributeValue{}
+	if cause != nil {
+		attrs["error"] = sqstypes.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(slackout.Truncate(cause.Error(), 1024)),
+		}
+	}
+	_, err = s.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(s.queueURL),
+		MessageBody:       aws.String(string(body)),
+		MessageAttributes: attrs,
+	})
+	if err != nil {
+		return fmt.Errorf("send to park queue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package salvage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/slackout/slackouttest"
+)
+
+func TestMarshalInvalidDetail(t *testing.T) {
+	evt := events.CloudWatchEvent{ID: "e-1", Source: "aws.guardduty", Detail: json.RawMessage(`{"id":`)}
+	raw, err := Marshal(evt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Detail != `{"id":` {
+		t.Errorf("detail = %q, want the original bytes as a string", got.Detail)
+	}
+}
+
+func TestSlackPark(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tests := []struct {
+		name   string
+		detail string
+		reply  string
+	}{
+		{name: "inline", detail: `{"findings":[]}`, reply: "chat.postMessage"},
+		{name: "upload", detail: `"` + strings.Repeat("x", inlineLimit) + `"`, reply: "files.upload"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poster := &slackouttest.FakePoster{}
+			evt := events.CloudWatchEvent{ID: "e-1", Source: "aws.securityhub", Detail: json.RawMessage(tt.detail)}
+			if err := NewSlack(log, poster, "C0123456").Park(context.Background(), evt, errors.New("boom")); err != nil {
+				t.Fatal(err)
+			}
+			calls := poster.Calls()
+			if len(calls) != 2 {
+				t.Fatalf("got %d calls, want 2", len(calls))
+			}
+			if calls[1].Method != tt.reply || calls[1].ThreadTS == "" {
+				t.Errorf("reply = %s in thread %q, want %s in the parent's thread", calls[1].Method, calls[1].ThreadTS, tt.reply)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// check.go
+//
+// startup checks — verify the token and that every configured channel exists
+// before the first finding arrives.
+
+package slackout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/slack-go/slack"
+)
+
+// CheckAPI is the slice of the slack api used by Check.
+type CheckAPI interface {
+	AuthTestContext(ctx context.Context) (*slack.AuthTestResponse, error)
+	GetConversationInfoContext(ctx context.Context, in *slack.GetConversationInfoInput) (*slack.Channel, error)
+}
+
+// Check verifies the token with auth.test and that each channel can be looked
+// up. all problems are returned, joined. a channel the bot is not a member of
+// is only logged, as chat:write.public posts to public channels without
+// joining.
+func Check(ctx context.Context, log *slog.Logger, api CheckAPI, channels []string) error {
+	if _, err := api.AuthTestContext(ctx); err != nil {
+		// every other call would fail the same way.
+		return fmt.Errorf("slack auth.test: %w", err)
+	}
+
+	var errs []error
+	for _, ch := range channels {
+		info, err := api.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: ch})
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("channel %s: conversations.info: %w", ch, err))
+		case !info.IsMember && !info.IsIM:
+			log.Warn("bot is not a member of the channel; posts need chat:write.public", "channel", ch, "name", info.Name)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,49 @@
+// client.go
+//
+// slack http client — request timeout, egress proxy and extra root cas for
+// vpc-attached lambdas that reach slack through a tls-inspecting proxy.
+
+package slackout
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/config"
+)
+
+// NewHTTPClient builds the client passed to slack.OptionHTTPClient. without a
+// proxy override it honors HTTPS_PROXY/NO_PROXY like the default transport.
+func NewHTTPClient(ctx context.Context, cfg config.Config) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.SlackProxyURL != "" {
+		proxy, err := url.Parse(cfg.SlackProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("env var APP_SLACK_PROXY_URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if cfg.SlackCABundle != "" {
+		pem, err := config.ReadSource(ctx, cfg.SlackCABundle)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(pem)) {
+			return nil, errors.New("env var APP_SLACK_CA_BUNDLE: no certificates found")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{Timeout: cfg.SlackTimeout, Transport: transport}, nil
+}
@@ -0,0 +1,294 @@
+// details.go
+//
+// finding details — malware scan and runtime monitoring findings get
+// dedicated sections with the scanned volumes, threats, process lineage and
+// container context instead of just the description. inspector2 and health
+// events get their source-specific sections here too.
+
+package slackout
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+// maxDetailItems caps list entries per section; the full list is in the
+// threaded finding json.
+const maxDetailItems = 5
+
+type Detail struct {
+	Title string
+	Lines []string
+}
+
+// mrkdwnEscaper escapes the characters slack treats as control sequences, so
+// command lines and file paths render verbatim.
+var mrkdwnEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Text renders the section as slack mrkdwn.
+func (d Detail) Text() string {
+	return "*" + d.Title + "*\n" + mrkdwnEscaper.Replace(strings.Join(d.Lines, "\n"))
+}
+
+// RenderDetails returns the sections that apply to the finding, in display
+// order. most findings have none.
+func RenderDetails(f finding.Finding) []Detail {
+	var details []Detail
+	if d, ok := threatIntelDetail(f); ok {
+		details = append(details, d)
+	}
+	if d, ok := malwareDetail(f); ok {
+		details = append(details, d)
+	}
+	if d, ok := processDetail(f); ok {
+		details = append(details, d)
+	}
+	if d, ok := kubernetesDetail(f); ok {
+		details = append(details, d)
+	}
+	if d, ok := ecsDetail(f); ok {
+		details = append(details, d)
+	}
+	if d, ok := inspectorDetail(f); ok {
+		details = append(details, d)
+	}
+	if d, ok := healthDetail(f); ok {
+		details = append(details, d)
+	}
+	return details
+}
+
+func threatIntelDetail(f finding.Finding) (Detail, bool) {
+	d := Detail{Title: "Threat Intel"}
+	ips := f.RemoteIPs()
+	for _, r := range ips[:min(len(ips), maxDetailItems)] {
+		line := "• `" + r.IP() + "`"
+		if loc := joinNonEmpty(", ", r.City.CityName, r.Country.CountryName); loc != "" {
+			line += " " + loc
+		}
+		org := joinNonEmpty(" / ", r.Organization.Isp, r.Organization.Org)
+		if org == "" {
+			org = r.Organization.AsnOrg
+		}
+		if r.Organization.Asn != "" {
+			org = joinNonEmpty(" ", org, "(AS"+r.Organization.Asn+")")
+		}
+		if org != "" {
+			line += " · " + org
+		}
+		d.Lines = append(d.Lines, line)
+	}
+	if n := len(ips) - maxDetailItems; n > 0 {
+		d.Lines = append(d.Lines, fmt.Sprintf("…and %d more remote ips", n))
+	}
+	if domain := f.Domain(); domain != "" {
+		d.Lines = append(d.Lines, "Domain: `"+domain+"`")
+	}
+	for _, tl := range f.ThreatLists() {
+		line := "Threat list: " + tl.ThreatListName
+		if len(tl.ThreatNames) > 0 {
+			line += " (" + strings.Join(tl.ThreatNames, ", ") + ")"
+		}
+		d.Lines = append(d.Lines, line)
+	}
+	return d, len(d.Lines) > 0
+}
+
+func malwareDetail(f finding.Finding) (Detail, bool) {
+	scan := f.Service.EbsVolumeScanDetails
+	if scan == nil {
+		return Detail{}, false
+	}
+	det := scan.ScanDetections
+	d := Detail{Title: "Malware Scan"}
+
+	d.Lines = append(d.Lines, fmt.Sprintf("Scanned %d volumes, %d files (%d GB)",
+		det.ScannedItemCount.Volumes, det.ScannedItemCount.Files, det.ScannedItemCount.TotalGb))
+	if hs := det.HighestSeverityThreatDetails; hs.ThreatName != "" {
+		d.Lines = append(d.Lines, fmt.Sprintf("%d infected files, highest severity *%s*: `%s`",
+			det.ThreatsDetectedItemCount.Files, strings.ToLower(hs.Severity), hs.ThreatName))
+	}
+
+	threats := det.ThreatDetectedByName.ThreatNames
+	for _, t := range threats[:min(len(threats), maxDetailItems)] {
+		files := make([]string, 0, maxDetailItems)
+		for _, fp := range t.FilePaths[:min(len(t.FilePaths), maxDetailItems)] {
+			files = append(files, "`"+fp.FilePath+"`")
+		}
+		line := fmt.Sprintf("• `%s` (%s, %d files)", t.Name, strings.ToLower(t.Severity), t.ItemCount)
+		if len(files) > 0 {
+			line += ": " + strings.Join(files, ", ")
+		}
+		d.Lines = append(d.Lines, line)
+	}
+	if n := det.ThreatDetectedByName.UniqueThreatNameCount - min(len(threats), maxDetailItems); n > 0 {
+		d.Lines = append(d.Lines, fmt.Sprintf("…and %d more threats", n))
+	}
+
+	if vd := f.Resource.EbsVolumeDetails; vd != nil {
+		for _, v := range vd.ScannedVolumeDetails[:min(len(vd.ScannedVolumeDetails), maxDetailItems)] {
+			d.Lines = append(d.Lines, fmt.Sprintf("• volume `%s` %s (%d GB, %s)",
+				path.Base(v.VolumeArn), v.DeviceName, v.VolumeSizeInGB, v.VolumeType))
+		}
+		if n := len(vd.SkippedVolumeDetails); n > 0 {
+			d.Lines = append(d.Lines, fmt.Sprintf("%d volumes skipped", n))
+		}
+	}
+	return d, true
+}
+
+func processDetail(f finding.Finding) (Detail, bool) {
+	rt := f.Service.RuntimeDetails
+	if rt == nil || rt.Process == nil {
+		return Detail{}, false
+	}
+	p := rt.Process
+	d := Detail{Title: "Process"}
+	d.Lines = append(d.Lines, fmt.Sprintf("`%s` pid %d, user %s (euid %d)",
+		orDefault(p.ExecutablePath, p.Name), p.Pid, orDefault(p.User, "unknown"), p.Euid))
+
+	if len(p.Lineage) > 0 {
+		// lineage is ordered from the parent upwards; show it root first.
+		chain := make([]string, 0, len(p.Lineage)+1)
+		for _, l := range slices.Backward(p.Lineage) {
+			chain = append(chain, orDefault(l.Name, path.Base(l.ExecutablePath)))
+		}
+		chain = append(chain, orDefault(p.Name, path.Base(p.ExecutablePath)))
+		d.Lines = append(d.Lines, "Lineage: `"+strings.Join(chain, " → ")+"`")
+	}
+
+	if c := rt.Context; c != nil {
+		for _, kv := range [][2]string{
+			{"Script", c.ScriptPath},
+			{"Threat file", c.ThreatFilePath},
+			{"Tool", c.ToolName},
+			{"Command", c.CommandLineExample},
+		} {
+			if kv[1] != "" {
+				d.Lines = append(d.Lines, fmt.Sprintf("%s: `%s`", kv[0], kv[1]))
+			}
+		}
+	}
+	return d, true
+}
+
+func kubernetesDetail(f finding.Finding) (Detail, bool) {
+	k := f.Resource.KubernetesDetails
+	if k == nil {
+		return Detail{}, false
+	}
+	d := Detail{Title: "Kubernetes"}
+	if c := f.Resource.EksClusterDetails; c != nil {
+		d.Lines = append(d.Lines, "Cluster: `"+c.Name+"`")
+	}
+	if w := k.KubernetesWorkloadDetails; w != nil {
+		line := fmt.Sprintf("Workload: %s `%s/%s`", w.Type, w.Namespace, w.Name)
+		if w.HostNetwork {
+			line += " (host network)"
+		}
+		d.Lines = append(d.Lines, line)
+		d.Lines = append(d.Lines, containerLines(w.Containers)...)
+	}
+	if u := k.KubernetesUserDetails; u != nil && u.Username != "" {
+		d.Lines = append(d.Lines, "User: `"+u.Username+"`")
+	}
+	return d, len(d.Lines) > 0
+}
+
+func ecsDetail(f finding.Finding) (Detail, bool) {
+	c := f.Resource.EcsClusterDetails
+	if c == nil {
+		return Detail{}, false
+	}
+	d := Detail{Title: "ECS", Lines: []string{"Cluster: `" + c.Name + "`"}}
+	if t := c.TaskDetails; t != nil {
+		d.Lines = append(d.Lines, fmt.Sprintf("Task: `%s` (%s)", path.Base(t.DefinitionArn), t.LaunchType))
+		d.Lines = append(d.Lines, containerLines(t.Containers)...)
+	}
+	return d, true
+}
+
+func containerLines(containers []finding.Container) []string {
+	var lines []string
+	for _, c := range containers[:min(len(containers), maxDetailItems)] {
+		line := fmt.Sprintf("• container `%s` image `%s`", c.Name, c.Image)
+		if c.SecurityContext != nil && c.SecurityContext.Privileged {
+			line += " *privileged*"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+func inspectorDetail(f finding.Finding) (Detail, bool) {
+	in := f.Inspector
+	if in == nil {
+		return Detail{}, false
+	}
+	d := Detail{Title: "Inspector"}
+	for _, r := range in.Resources[:min(len(in.Resources), maxDetailItems)] {
+		d.Lines = append(d.Lines, fmt.Sprintf("• %s `%s`", r.Type, r.ID))
+	}
+	if pv := in.PackageVulnerabilityDetails; pv != nil {
+		// lines are escaped, so the source url is left for slack to auto-link.
+		d.Lines = append(d.Lines, strings.TrimSpace("Vulnerability: `"+pv.VulnerabilityID+"` "+pv.SourceURL))
+		for _, pkg := range pv.VulnerablePackages[:min(len(pv.VulnerablePackages), maxDetailItems)] {
+			line := fmt.Sprintf("• `%s` %s", pkg.Name, pkg.Version)
+			if pkg.FixedInVersion != "" && pkg.FixedInVersion != "NotAvailable" {
+				line += " → fixed in " + pkg.FixedInVersion
+			}
+			d.Lines = append(d.Lines, line)
+		}
+	}
+	if in.FixAvailable != "" {
+		d.Lines = append(d.Lines, "Fix available: "+strings.ToLower(in.FixAvailable))
+	}
+	if rec := in.Remediation.Recommendation.Text; rec != "" && rec != "None Provided" {
+		d.Lines = append(d.Lines, "Remediation: "+rec)
+	}
+	return d, len(d.Lines) > 0
+}
+
+func healthDetail(f finding.Finding) (Detail, bool) {
+	h := f.Health
+	if h == nil {
+		return Detail{}, false
+	}
+	d := Detail{Title: "AWS Health"}
+	d.Lines = append(d.Lines, fmt.Sprintf("%s · %s · status %s", h.Service, h.EventTypeCategory, h.StatusCode))
+	if h.StartTime != "" {
+		window := "Start: " + h.StartTime
+		if h.EndTime != "" {
+			window += " · End: " + h.EndTime
+		}
+		d.Lines = append(d.Lines, window)
+	}
+	for _, e := range h.AffectedEntities[:min(len(h.AffectedEntities), maxDetailItems)] {
+		d.Lines = append(d.Lines, "• `"+e+"`")
+	}
+	if n := len(h.AffectedEntities) - maxDetailItems; n > 0 {
+		d.Lines = append(d.Lines, fmt.Sprintf("…and %d more affected resources", n))
+	}
+	return d, true
+}
@@ -0,0 +1,125 @@
+// limits.go
+//
+// message size guard — slack rejects the whole message with invalid_blocks
+// when a single text object or the block count is over its limit. rendered
+// blocks are trimmed to fit and whatever was cut is handed back so it can be
+// posted in the thread instead.
+
+package slackout
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/slack-go/slack"
+)
+
+// https://api.slack.com/reference/block-kit/blocks
+const (
+	maxBlocks      = 50
+	maxHeaderText  = 150
+	maxSectionText = 3000
+	maxFieldText   = 2000
+	maxContextText = 2000
+	maxButtonText  = 75
+	maxMessageText = 4000
+)
+
+// Overflow is what FitBlocks cut from a message.
+type Overflow struct {
+	// Texts are the full versions of truncated text objects.
+	Texts []string
+	// Blocks did not fit under the block limit.
+	Blocks []slack.Block
+}
+
+func (o Overflow) Empty() bool {
+	return len(o.Texts) == 0 && len(o.Blocks) == 0
+}
+
+// FitBlocks truncates oversized text objects with an ellipsis and caps the
+// block count. blocks are modified in place.
+func FitBlocks(blocks []slack.Block) ([]slack.Block, Overflow) {
+	var o Overflow
+	fit := func(t *slack.TextBlockObject, limit int) {
+		if t == nil {
+			return
+		}
+		if short := Truncate(t.Text, limit); short != t.Text {
+			o.Texts = append(o.Texts, t.Text)
+			t.Text = short
+		}
+	}
+
+	for _, b := range blocks {
+		switch b := b.(type) {
+		case *slack.HeaderBlock:
+			fit(b.Text, maxHeaderText)
+		case *slack.SectionBlock:
+			fit(b.Text, maxSectionText)
+			for _, field := range b.Fields {
+				fit(field, maxFieldText)
+			}
+		case *slack.ContextBlock:
+			for _, el := range b.ContextElements.Elements {
+				if t, ok := el.(*slack.TextBlockObject); ok {
+					fit(t, maxContextText)
+				}
+			}
+		case *slack.ActionBlock:
+			if b.Elements == nil {
+				continue
+			}
+			for _, el := range b.Elements.ElementSet {
+				if btn, ok := el.(*slack.ButtonBlockElement); ok && btn.Text != nil {
+					// button labels are not worth a thread reply
+					btn.Text.Text = Truncate(btn.Text.Text, maxButtonText)
+				}
+			}
+		}
+	}
+
+	if len(blocks) > maxBlocks {
+		keep := maxBlocks - 1
+		o.Blocks = append(o.Blocks, blocks[keep:]...)
+		note := fmt.Sprintf("_%d more blocks continued in thread_", len(blocks)-keep)
+		blocks = append(blocks[:keep:keep], slack.NewContextBlock("",
+			slack.NewTextBlockObject("mrkdwn", note, false, false),
+		))
+	}
+	return blocks, o
+}
+
+// Truncate shortens s to at most limit characters, ending in an ellipsis.
+func Truncate(s string, limit int) string {
+	r := []rune(s)
+	if len(r) <= limit {
+		return s
+	}
+	return string(r[:limit-1]) + "…"
+}
+
+// isPayloadRejected reports whether slack refused the message because of its
+// size or shape, as opposed to auth or availability problems.
+func isPayloadRejected(err error) bool {
+	var resp slack.SlackErrorResponse
+	if !errors.As(err, &resp) {
+		return false
+	}
+	switch resp.Err {
+	case "invalid_blocks", "invalid_blocks_format", "msg_too_long", "invalid_attachments":
+		return true
+	default:
+		return false
+	}
+}
+
+// fallbackBlocks is the minimal message posted when slack rejects the
+// rendered one, so the finding is never lost to formatting.
+func fallbackBlocks(title, consoleURL string) []slack.Block {
+	title = Truncate(title, maxSectionText-len(consoleURL)-32)
+	text := fmt.Sprintf("*%s*\n<%s|View in Console>", title, consoleURL)
+	return []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", text, false, false), nil, nil),
+	}
+}
@@ -0,0 +1,46 @@
+package slackout
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+func TestFitBlocksCapsBlockCount(t *testing.T) {
+	var blocks []slack.Block
+	for range 60 {
+		blocks = append(blocks, slack.NewDividerBlock())
+	}
+
+	fitted, o := FitBlocks(blocks)
+	if len(fitted) != maxBlocks {
+		t.Fatalf("got %d blocks, want %d", len(fitted), maxBlocks)
+	}
+	if len(o.Blocks) != 11 {
+		t.Errorf("got %d overflow blocks, want 11", len(o.Blocks))
+	}
+	if _, ok := fitted[maxBlocks-1].(*slack.ContextBlock); !ok {
+		t.Errorf("last block is %T, want a continuation note", fitted[maxBlocks-1])
+	}
+}
+
+func TestFitBlocksTruncatesText(t *testing.T) {
+	long := strings.Repeat("é", maxSectionText+10)
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject("plain_text", long, false, false), nil, nil),
+	}
+
+	fitted, o := FitBlocks(blocks)
+	text := fitted[0].(*slack.SectionBlock).Text.Text
+	if n := utf8.RuneCountInString(text); n != maxSectionText {
+		t.Errorf("got %d characters, want %d", n, maxSectionText)
+	}
+	if !strings.HasSuffix(text, "…") {
+		t.Error("truncated text should end with an ellipsis")
+	}
+	if len(o.Texts) != 1 || o.Texts[0] != long {
+		t.Error("overflow should carry the full text")
+	}
+}
@@ -0,0 +1,190 @@
+// notifier.go
+//
+// slack notifier — renders a finding with the message template, picks the
+// channel, posts the summary and follows up in its thread.
+
+package slackout
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/ack"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/config"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/correlate"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/i18n"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/knowledge"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/routing"
+)
+
+// Destination is a workspace destination with the poster for its token.
+type Destination struct {
+	routing.Destination
+	Poster SlackPoster
+}
+
+type Notifier struct {
+	cfg      config.Config
+	log      *slog.Logger
+	poster   SlackPoster
+	template *Template
+	runbooks knowledge.Runbooks
+	routes   routing.Rules
+	dests    []Destination
+	style    Style
+	catalog  i18n.Catalog
+	// threads correlates findings by resource; nil posts each on its own.
+	threads correlate.Store
+}
+
+func NewNotifier(cfg config.Config, log *slog.Logger, poster SlackPoster, tmpl *Template, runbooks knowledge.Runbooks, routes routing.Rules, dests []Destination, threads correlate.Store) *Notifier {
+	// config.Load rejects unknown locales; anything else falls back to english.
+	catalog, _ := i18n.Load(cfg.Locale)
+	return &Notifier{
+		cfg:      cfg,
+		log:      log,
+		poster:   poster,
+		template: tmpl,
+		runbooks: runbooks,
+		routes:   routes,
+		dests:    dests,
+		style:    NewStyle(cfg),
+		catalog:  catalog,
+		threads:  threads,
+	}
+}
+
+func (n *Notifier) Notify(ctx context.Context, f finding.Finding) error {
+	blocks, err := n.template.Render(n.templateData(f))
+	if err != nil {
+		return err
+	}
+
+	poster, channel := n.Target(f)
+	color := n.style.ColorFor(f)
+	key, thread, opened := n.joinThread(ctx, f, channel)
+	if thread.TS != "" {
+		blocks = append([]slack.Block{n.relatedBlock(thread, f)}, blocks...)
+	}
+	blocks, overflow := FitBlocks(blocks)
+	text := Truncate(f.Title, maxMessageText)
+
+	ts, err := post(ctx, poster, channel, thread.TS, text, color, blocks)
+	if isPayloadRejected(err) {
+		n.log.Warn("slack rejected the rendered message, posting fallback", "finding_id", f.ID, "error", err)
+		ts, err = post(ctx, poster, channel, thread.TS, text, color, fallbackBlocks(f.Title, f.ConsoleURL))
+	}
+	if err != nil {
+		if opened {
+			if err := n.threads.Release(ctx, key); err != nil {
+				n.log.Warn("failed to release finding thread", "finding_id", f.ID, "error", err)
+			}
+		}
+		return err
+	}
+	switch {
+	case opened:
+		if err := n.threads.Save(ctx, key, ts); err != nil {
+			n.log.Warn("failed to save finding thread", "finding_id", f.ID, "error", err)
+		}
+	case thread.TS != "":
+		// follow-ups go to the resource's thread; a reply has none of its own.
+		ts = thread.TS
+		if err := n.updateThread(ctx, poster, channel, thread, f); err != nil {
+			n.log.Warn("failed to update related findings count", "finding_id", f.ID, "error", err)
+		}
+	}
+
+	// the summary is already delivered; a failed follow-up must not trigger a
+	// retry that would post it twice.
+	if !overflow.Empty() {
+		if err := postOverflow(ctx, poster, channel, ts, f, overflow); err != nil {
+			n.log.Warn("failed to post message overflow", "finding_id", f.ID, "error", err)
+		}
+	}
+	if n.cfg.ThreadDetails {
+		if err := postThreadDetails(ctx, poster, channel, ts, f); err != nil {
+			n.log.Warn("failed to post thread details", "finding_id", f.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+func post(ctx context.Context, poster SlackPoster, channel, threadTS, text, color string, blocks []slack.Block) (string, error) {
+	if color != "" {
+		return poster.PostAttachment(ctx, channel, threadTS, text, color, blocks)
+	}
+	return poster.PostMessage(ctx, channel, threadTS, text, blocks)
+}
+
+// Target picks the workspace and channel: the first matching destination,
+// else the default workspace and ChannelFor. type routes and account channels
+// name channels of the default workspace, so they do not apply to the others.
+func (n *Notifier) Target(f finding.Finding) (SlackPoster, string) {
+	for _, d := range n.dests {
+		if d.Matches(f) {
+			return d.Poster, d.Channel
+		}
+	}
+	return n.poster, n.ChannelFor(f)
+}
+
+// ChannelFor picks the channel: the first matching type route, then the
+// account's channel, then APP_SLACK_CHANNEL.
+func (n *Notifier) ChannelFor(f finding.Finding) string {
+	if ch, ok := n.routes.Channel(f.Type); ok {
+		return ch
+	}
+	if ch, ok := n.cfg.AccountChannels[f.AccountID]; ok {
+		return ch
+	}
+	return n.cfg.SlackChannel
+}
+
+func (n *Notifier) templateData(f finding.Finding) TemplateData {
+	data := TemplateData{Finding: f, Emoji: n.style.EmojiFor(f), Details: RenderDetails(f)}
+	for i := range data.Details {
+		data.Details[i].Title = n.catalog.T(data.Details[i].Title)
+	}
+	if n.cfg.AckEnabled {
+		data.AckValue = ack.NewRef(f).Value()
+	}
+	for _, l := range f.ResourceLinks() {
+		data.Links = append(data.Links, Link{Label: n.catalog.T(l.Label), URL: l.URL})
+	}
+	if n.cfg.ThreatIntelLinks {
+		data.Links = append(data.Links, threatIntelLinks(f)...)
+	}
+	if f.Source == finding.SourceGuardDuty && f.Type != "" {
+		data.Links = append(data.Links, Link{Label: n.catalog.T("Finding Docs"), URL: knowledge.DocsURL(f.Type)})
+	}
+	if rb, ok := n.runbooks.Lookup(f.Type); ok {
+		label := rb.Label
+		if label == "" {
+			label = n.catalog.T("Runbook")
+		}
+		data.Links = append(data.Links, Link{Label: label, URL: rb.URL})
+		data.RunbookHint = rb.Hint
+	}
+	return data
+}
+
+// threatIntelLinks looks up the first remote ip, or the queried domain, in
+// public reputation services.
+func threatIntelLinks(f finding.Finding) []Link {
+	if ips := f.RemoteIPs(); len(ips) > 0 {
+		ip := url.PathEscape(ips[0].IP())
+		return []Link{
+			{Label: "AbuseIPDB", URL: "https://www.abuseipdb.com/check/" + ip},
+			{Label: "VirusTotal", URL: "https://www.virustotal.com/gui/ip-address/" + ip},
+		}
+	}
+	if domain := f.Domain(); domain != "" {
+		return []Link{{Label: "VirusTotal", URL: "https://www.virustotal.com/gui/domain/" + url.PathEscape(domain)}}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package slackout_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/config"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/correlate"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/knowledge"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/routing"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/slackout"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/slackout/slackouttest"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+const testRoutes = `[{"match": "Execution:Runtime/*", "channel": "C0PLATFORM"}, {"match": "*S3*", "channel": "C0DATASEC"}]`
+
+const testRunbooks = `[{"match": "CryptoCurrency:*", "url": "https://wiki.example.com/runbooks/crypto", "label": "Crypto Runbook", "hint": "Isolate the instance before investigating."}]`
+
+func testConfig() config.Config {
+	return config.Config{
+		SlackChannel:     "C0GUARDDUTY",
+		SlackStyle:       config.SlackStyleBlocks,
+		ThreadDetails:    true,
+		ThreatIntelLinks: true,
+		AckEnabled:       true,
+		Runbooks:         testRunbooks,
+		Routes:           testRoutes,
+		AccountChannels:  map[string]string{"210987654321": "C0DATA"},
+	}
+}
+
+func newNotifier(t *testing.T, cfg config.Config, poster slackout.SlackPoster, dests ...slackout.Destination) *slackout.Notifier {
+	t.Helper()
+	return newCorrelatingNotifier(t, cfg, poster, nil, dests...)
+}
+
+func newCorrelatingNotifier(t *testing.T, cfg config.Config, poster slackout.SlackPoster, threads correlate.Store, dests ...slackout.Destination) *slackout.Notifier {
+	t.Helper()
+	ctx := context.Background()
+	tmpl, err := slackout.LoadTemplate(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runbooks, err := knowledge.LoadRunbooks(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes, err := routing.LoadRules(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return slackout.NewNotifier(cfg, log, poster, tmpl, runbooks, routes, dests, threads)
+}
+
+// loadFinding parses testdata/findings/<name>.json. inspector- and health-
+// prefixed fixtures are parsed as those sources.
+func loadFinding(t *testing.T, name string) finding.Finding {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "findings", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	detailType := finding.DetailTypeGuardDuty
+	switch {
+	case strings.HasPrefix(name, "inspector-"):
+		detailType = finding.DetailTypeInspector
+	case strings.HasPrefix(name, "health-"):
+		detailType = finding.DetailTypeHealth
+	}
+	f, err := finding.ParseEvent(detailType, raw, "")
+	if err != nil {
+		t.Fatalf("parse %s: %v", name, err)
+	}
+	return f
+}
+
+// assertGolden compares the recorded calls with testdata/golden/<name>.json.
+// run `go test ./internal/slackout -update` after an intended format change.
+func assertGolden(t *testing.T, name string, calls []slackouttest.Call) {
+	t.Helper()
+	got, err := json.MarshalIndent(calls, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("payload differs from %s (run with -update to accept):\n%s", path, got)
+	}
+}
+
+func TestNotifyGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "findings", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no fixtures found")
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			poster := &slackouttest.FakePoster{}
+			if err := newNotifier(t, testConfig(), poster).Notify(context.Background(), loadFinding(t, name)); err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, name, poster.Calls())
+		})
+	}
+}
+
+func TestNotifyAttachmentStyle(t *testing.T) {
+	cfg := testConfig()
+	cfg.SlackStyle = config.SlackStyleAttachment
+	cfg.ThreadDetails = false
+
+	poster := &slackouttest.FakePoster{}
+	if err := newNotifier(t, cfg, poster).Notify(context.Background(), loadFinding(t, "ec2-bitcoin-tool")); err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "attachment-style", poster.Calls())
+}
+
+func TestNotifyLocale(t *testing.T) {
+	cfg := testConfig()
+	cfg.Locale = "ja"
+	cfg.ThreadDetails = false
+
+	poster := &slackouttest.FakePoster{}
+	if err := newNotifier(t, cfg, poster).Notify(context.Background(), loadFinding(t, "s3-exfiltration-reobserved")); err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "locale-ja", poster.Calls())
+}
+
+func TestNotifyDestinations(t *testing.T) {
+	cfg := testConfig()
+	cfg.Destinations = `[
+		{"name": "sandbox", "token": "xoxb-dev", "channel": "C0SANDBOX", "tags": {"env": "sandbox*"}},
+		{"name": "prod", "token": "xoxb-prod", "channel": "C0PRODSEC", "accounts": ["210987654321"]}
+	]`
+	dests, err := routing.LoadDestinations(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		account string
+		tags    map[string]string
+		want    string // destination name, or "" for the default workspace
+		channel string
+	}{
+		{name: "tag", account: "123456789012", tags: map[string]string{"env": "sandbox-eu"}, want: "sandbox", channel: "C0SANDBOX"},
+		{name: "account", account: "210987654321", want: "prod", channel: "C0PRODSEC"},
+		{name: "no match", account: "123456789012", tags: map[string]string{"env": "prod"}, channel: "C0GUARDDUTY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaultPoster := &slackouttest.FakePoster{}
+			posters := map[string]*slackouttest.FakePoster{"": defaultPoster}
+			var targets []slackout.Destination
+			for _, d := range dests {
+				posters[d.Name] = &slackouttest.FakePoster{}
+				targets = append(targets, slackout.Destination{Destination: d, Poster: posters[d.Name]})
+			}
+
+			f := loadFinding(t, "ec2-port-probe")
+			f.AccountID, f.AccountTags = tt.account, tt.tags
+			if err := newNotifier(t, cfg, defaultPoster, targets...).Notify(context.Background(), f); err != nil {
+				t.Fatal(err)
+			}
+			for name, p := range posters {
+				calls := p.Calls()
+				if name != tt.want {
+					if len(calls) > 0 {
+						t.Errorf("%q workspace got %d calls, want none", name, len(calls))
+					}
+					continue
+				}
+				if len(calls) == 0 || calls[0].Channel != tt.channel {
+					t.Errorf("%q workspace calls = %+v, want a post to %s", name, calls, tt.channel)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyCorrelatesResource(t *testing.T) {
+	cfg := testConfig()
+	cfg.ThreadDetails = false
+	poster := &slackouttest.FakePoster{}
+	n := newCorrelatingNotifier(t, cfg, poster, correlate.NewMemory(time.Hour))
+	ctx := context.Background()
+
+	first := loadFinding(t, "ec2-port-probe")
+	second := loadFinding(t, "ec2-bitcoin-tool")
+	second.AccountID, second.Resource = first.AccountID, first.Resource
+	if err := n.Notify(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	// someone acknowledges the parent before the related finding arrives.
+	parent := poster.Calls()[0]
+	acked := append(slices.Clone(parent.Blocks), slack.NewContextBlock("ack-status",
+		slack.NewTextBlockObject(slack.MarkdownType, ":eyes: Acknowledged by <@U1>", false, false)))
+	if err := poster.UpdateMessage(ctx, parent.Channel, "1700000000.000001", parent.Text, parent.Color, acked); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := poster.Calls()
+	if len(calls) != 4 {
+		t.Fatalf("got %d calls, want the parent, the ack, one reply and the parent update", len(calls))
+	}
+	if calls[0].ThreadTS != "" {
+		t.Errorf("parent posted in thread %q", calls[0].ThreadTS)
+	}
+	if parent := "1700000000.000001"; calls[2].ThreadTS != parent {
+		t.Errorf("related finding posted in thread %q, want a reply to %s", calls[2].ThreadTS, parent)
+	}
+	want := "1 related findings on i-0ab1c2d3e4f5g6h7"
+	raw, _ := json.Marshal(calls[2].Blocks[0])
+	if !strings.Contains(string(raw), want) {
+		t.Errorf("reply does not open with %q: %s", want, raw)
+	}
+
+	edit := calls[3]
+	if edit.Method != "chat.update" || edit.TS != "1700000000.000001" {
+		t.Fatalf("got %s of %q, want chat.update of the parent", edit.Method, edit.TS)
+	}
+	if len(edit.Blocks) != len(acked)+1 {
+		t.Errorf("updated parent has %d blocks, want %d", len(edit.Blocks), len(acked)+1)
+	}
+	raw, _ = json.Marshal(edit.Blocks)
+	if !strings.Contains(string(raw), want) || !strings.Contains(string(raw), "Acknowledged by") {
+		t.Errorf("updated parent should keep the ack status and count the related finding: %s", raw)
+	}
+}
+
+func TestNotifyReturnsPostError(t *testing.T) {
+	poster := &slackouttest.FakePoster{Err: errors.New("channel_not_found")}
+	err := newNotifier(t, testConfig(), poster).Notify(context.Background(), loadFinding(t, "ec2-port-probe"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if n := len(poster.Calls()); n != 1 {
+		t.Errorf("expected no thread replies after a failed post, got %d calls", n)
+	}
+}
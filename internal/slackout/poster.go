@@ -0,0 +1,213 @@
+// poster.go
+//
+// slack delivery — thin wrapper around the slack client that can write the
+// payloads to a dry-run output, or the log, instead of posting them.
+
+package slackout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// SlackPoster is the slice of the slack api the notifier needs. Poster is the
+// real implementation; tests use slackouttest.FakePoster.
+type SlackPoster interface {
+	PostMessage(ctx context.Context, channel, threadTS, text string, blocks []slack.Block) (string, error)
+	PostAttachment(ctx context.Context, channel, threadTS, text, color string, blocks []slack.Block) (string, error)
+	UploadFile(ctx context.Context, channel, threadTS, filename, content string) error
+	// Message returns the message at ts as it is now, including later edits.
+	Message(ctx context.Context, channel, ts string) (slack.Message, error)
+	// UpdateMessage replaces the message at ts; a color wraps the blocks in an
+	// attachment as PostAttachment does.
+	UpdateMessage(ctx context.Context, channel, ts, text, color string, blocks []slack.Block) error
+}
+
+// DryRunTS stands in for the message timestamp slack would have returned, so
+// dry-run replies still show which thread they belong to.
+const DryRunTS = "dry-run"
+
+// maxRateLimitRetries bounds how often a call is retried after slack answered
+// with a rate limit.
+const maxRateLimitRetries = 3
+
+type Poster struct {
+	client    *slack.Client
+	log       *slog.Logger
+	dryRun    bool
+	dryRunOut io.Writer
+	// outMu keeps concurrent dry-run payloads from interleaving.
+	outMu sync.Mutex
+}
+
+// NewPoster wraps client. in dry run mode payloads are written to dryRunOut,
+// or logged when it is nil.
+func NewPoster(client *slack.Client, log *slog.Logger, dryRun bool, dryRunOut io.Writer) *Poster {
+	return &Poster{client: client, log: log, dryRun: dryRun, dryRunOut: dryRunOut}
+}
+
+// PostMessage sends blocks to slack, optionally as a reply to threadTS, and
+// returns the message timestamp. in dry run mode the payload is written to
+// the dry-run output instead.
+func (p *Poster) PostMessage(ctx context.Context, channel, threadTS, text string, blocks []slack.Block) (string, error) {
+	if p.dryRun {
+		payload := map[string]any{"channel": channel, "text": text, "blocks": blocks}
+		if threadTS != "" {
+			payload["thread_ts"] = threadTS
+		}
+		return DryRunTS, p.writeDryRun(payload)
+	}
+
+	opts := []slack.MsgOption{
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionBlocks(blocks...),
+	}
+	if threadTS != "" {
+		opts = append(opts, slack.MsgOptionTS(threadTS))
+	}
+	var ts string
+	err := p.withRetry(ctx, func() (err error) {
+		_, ts, err = p.client.PostMessageContext(ctx, channel, opts...)
+		return err
+	})
+	return ts, err
+}
+
+// PostAttachment is PostMessage with the blocks wrapped in an attachment so
+// slack draws a color bar next to them.
+func (p *Poster) PostAttachment(ctx context.Context, channel, threadTS, text, color string, blocks []slack.Block) (string, error) {
+	attachment := slack.Attachment{Color: color, Blocks: slack.Blocks{BlockSet: blocks}}
+	if p.dryRun {
+		payload := map[string]any{"channel": channel, "text": text, "attachments": []slack.Attachment{attachment}}
+		if threadTS != "" {
+			payload["thread_ts"] = threadTS
+		}
+		return DryRunTS, p.writeDryRun(payload)
+	}
+
+	opts := []slack.MsgOption{
+		slack.MsgOptionText(text, false),
+		slack.MsgOptionAttachments(attachment),
+	}
+	if threadTS != "" {
+		opts = append(opts, slack.MsgOptionTS(threadTS))
+	}
+	var ts string
+	err := p.withRetry(ctx, func() (err error) {
+		_, ts, err = p.client.PostMessageContext(ctx, channel, opts...)
+		return err
+	})
+	return ts, err
+}
+
+func (p *Poster) Message(ctx context.Context, channel, ts string) (slack.Message, error) {
+	if p.dryRun {
+		return slack.Message{}, errors.New("dry run: messages are not posted")
+	}
+	var out *slack.GetConversationHistoryResponse
+	err := p.withRetry(ctx, func() (err error) {
+		out, err = p.client.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+			ChannelID: channel,
+			Latest:    ts,
+			Inclusive: true,
+			Limit:     1,
+		})
+		return err
+	})
+	if err != nil {
+		return slack.Message{}, err
+	}
+	if len(out.Messages) == 0 || out.Messages[0].Timestamp != ts {
+		return slack.Message{}, fmt.Errorf("message %s not found", ts)
+	}
+	return out.Messages[0], nil
+}
+
+func (p *Poster) UpdateMessage(ctx context.Context, channel, ts, text, color string, blocks []slack.Block) error {
+	opts := []slack.MsgOption{slack.MsgOptionText(text, false)}
+	payload := map[string]any{"channel": channel, "ts": ts, "text": text}
+	if color != "" {
+		attachment := slack.Attachment{Color: color, Blocks: slack.Blocks{BlockSet: blocks}}
+		opts = append(opts, slack.MsgOptionAttachments(attachment))
+		payload["attachments"] = []slack.Attachment{attachment}
+	} else {
+		opts = append(opts, slack.MsgOptionBlocks(blocks...))
+		payload["blocks"] = blocks
+	}
+	if p.dryRun {
+		return p.writeDryRun(payload)
+	}
+	return p.withRetry(ctx, func() error {
+		_, _, _, err := p.client.UpdateMessageContext(ctx, channel, ts, opts...)
+		return err
+	})
+}
+
+func (p *Poster) UploadFile(ctx context.Context, channel, threadTS, filename, content string) error {
+	if p.dryRun {
+		return p.writeDryRun(map[string]any{
+			"channel":   channel,
+			"thread_ts": threadTS,
+			"filename":  filename,
+			"content":   content,
+		})
+	}
+	return p.withRetry(ctx, func() error {
+		_, err := p.client.UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+			Channel:         channel,
+			ThreadTimestamp: threadTS,
+			Filename:        filename,
+			Title:           filename,
+			Content:         content,
+			FileSize:        len(content),
+			SnippetType:     "json",
+		})
+		return err
+	})
+}
+
+// withRetry runs call again after the delay slack asks for when it is rate
+// limited, so bursts such as backfills slow down instead of failing.
+func (p *Poster) withRetry(ctx context.Context, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+		var rateLimited *slack.RateLimitedError
+		if !errors.As(err, &rateLimited) || attempt == maxRateLimitRetries {
+			return err
+		}
+		p.log.Warn("slack rate limited, retrying", "retry_after", rateLimited.RetryAfter, "attempt", attempt+1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(rateLimited.RetryAfter):
+		}
+	}
+}
+
+func (p *Poster) writeDryRun(v any) error {
+	if p.dryRunOut == nil {
+		payload, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		p.log.Info("dry run: slack payload not sent", "payload", json.RawMessage(payload))
+		return nil
+	}
+
+	payload, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	p.outMu.Lock()
+	defer p.outMu.Unlock()
+	_, err = fmt.Fprintln(p.dryRunOut, string(payload))
+	return err
+}
@@ -0,0 +1,77 @@
+// related.go
+//
+// related findings — with correlation on, findings on a resource that already
+// has an open thread reply in it instead of posting a new message. the parent
+// and each reply note how many related findings the thread holds.
+
+package slackout
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/correlate"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+// relatedBlockID marks the context block counting the related findings.
+const relatedBlockID = "related"
+
+// joinThread returns the key of the finding's thread and, when another
+// finding opened it, the thread to reply in. opened reports that this finding
+// opens it and must save its message. the key is empty when correlation is off
+// or the resource is unknown.
+func (n *Notifier) joinThread(ctx context.Context, f finding.Finding, channel string) (key string, thread correlate.Thread, opened bool) {
+	if n.threads == nil {
+		return "", correlate.Thread{}, false
+	}
+	key = correlate.Key(f, channel)
+	if key == "" {
+		return "", correlate.Thread{}, false
+	}
+	thread, opened, err := n.threads.Join(ctx, key)
+	if err != nil {
+		n.log.Warn("failed to join finding thread, posting on its own", "finding_id", f.ID, "error", err)
+		return "", correlate.Thread{}, false
+	}
+	return key, thread, opened
+}
+
+// updateThread puts the number of findings replied in the thread on the
+// parent. the parent is edited as it is now, not as it was posted, so a triage
+// status set since is kept. it is best effort; the replies carry the count too.
+func (n *Notifier) updateThread(ctx context.Context, poster SlackPoster, channel string, thread correlate.Thread, f finding.Finding) error {
+	if thread.TS == DryRunTS {
+		return nil
+	}
+	msg, err := poster.Message(ctx, channel, thread.TS)
+	if err != nil {
+		return fmt.Errorf("get thread parent: %w", err)
+	}
+	blocks, color := msg.Blocks.BlockSet, ""
+	if len(msg.Attachments) > 0 {
+		// attachment style keeps the blocks inside the color bar.
+		blocks, color = msg.Attachments[0].Blocks.BlockSet, msg.Attachments[0].Color
+	}
+
+	related := n.relatedBlock(thread, f)
+	if i := slices.IndexFunc(blocks, func(b slack.Block) bool { return b.ID() == relatedBlockID }); i >= 0 {
+		blocks[i] = related
+	} else if i := slices.IndexFunc(blocks, func(b slack.Block) bool { return b.ID() == "actions" }); i >= 0 {
+		blocks = slices.Insert(blocks, i, related)
+	} else {
+		blocks = append(blocks, related)
+	}
+	return poster.UpdateMessage(ctx, channel, thread.TS, msg.Text, color, blocks)
+}
+
+// relatedBlock notes the number of findings replied in the thread so far.
+func (n *Notifier) relatedBlock(thread correlate.Thread, f finding.Finding) slack.Block {
+	return slack.NewContextBlock(relatedBlockID, slack.NewTextBlockObject(slack.MarkdownType,
+		":link: "+n.catalog.T("%d related findings on %s in this thread", thread.Count-1, f.Resource.ID()),
+		false, false,
+	))
+}
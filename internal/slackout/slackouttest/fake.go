@@ -0,0 +1,103 @@
+// fake.go
+//
+// in-memory slack poster for tests — records every call instead of talking to
+// slack.
+
+// Package slackouttest provides a fake slackout.SlackPoster.
+package slackouttest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// Call is one recorded request. only the fields relevant to the method are
+// set, so the json form doubles as a readable golden file.
+type Call struct {
+	Method   string        `json:"method"`
+	Channel  string        `json:"channel"`
+	ThreadTS string        `json:"thread_ts,omitempty"`
+	TS       string        `json:"ts,omitempty"`
+	Text     string        `json:"text,omitempty"`
+	Color    string        `json:"color,omitempty"`
+	Blocks   []slack.Block `json:"blocks,omitempty"`
+	Filename string        `json:"filename,omitempty"`
+	Content  string        `json:"content,omitempty"`
+}
+
+type FakePoster struct {
+	// Err, when set, is returned by every call after it is recorded.
+	Err error
+
+	mu    sync.Mutex
+	calls []Call
+	// messages holds the current content of each posted message by ts.
+	messages map[string]Call
+}
+
+func (p *FakePoster) PostMessage(_ context.Context, channel, threadTS, text string, blocks []slack.Block) (string, error) {
+	return p.record(Call{Method: "chat.postMessage", Channel: channel, ThreadTS: threadTS, Text: text, Blocks: blocks})
+}
+
+func (p *FakePoster) PostAttachment(_ context.Context, channel, threadTS, text, color string, blocks []slack.Block) (string, error) {
+	return p.record(Call{Method: "chat.postMessage", Channel: channel, ThreadTS: threadTS, Text: text, Color: color, Blocks: blocks})
+}
+
+func (p *FakePoster) UploadFile(_ context.Context, channel, threadTS, filename, content string) error {
+	_, err := p.record(Call{Method: "files.upload", Channel: channel, ThreadTS: threadTS, Filename: filename, Content: content})
+	return err
+}
+
+// Message returns the message at ts with any updates applied.
+func (p *FakePoster) Message(_ context.Context, channel, ts string) (slack.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := p.messages[ts]
+	if !ok || c.Channel != channel {
+		return slack.Message{}, fmt.Errorf("message %s not found", ts)
+	}
+	msg := slack.Message{Msg: slack.Msg{Timestamp: ts, Text: c.Text}}
+	if c.Color != "" {
+		msg.Attachments = []slack.Attachment{{Color: c.Color, Blocks: slack.Blocks{BlockSet: c.Blocks}}}
+	} else {
+		msg.Blocks = slack.Blocks{BlockSet: c.Blocks}
+	}
+	return msg, nil
+}
+
+func (p *FakePoster) UpdateMessage(_ context.Context, channel, ts, text, color string, blocks []slack.Block) error {
+	_, err := p.record(Call{Method: "chat.update", Channel: channel, TS: ts, Text: text, Color: color, Blocks: blocks})
+	return err
+}
+
+// Calls returns the recorded calls in order.
+func (p *FakePoster) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Call(nil), p.calls...)
+}
+
+// record stores the call and returns a fake timestamp derived from its
+// position, so thread replies can be traced back to their parent.
+func (p *FakePoster) record(c Call) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, c)
+	if p.Err != nil {
+		return "", p.Err
+	}
+	ts := fmt.Sprintf("1700000000.%06d", len(p.calls))
+	if c.Method == "chat.update" {
+		ts = c.TS
+	}
+	if c.Method != "files.upload" {
+		if p.messages == nil {
+			p.messages = map[string]Call{}
+		}
+		p.messages[ts] = c
+	}
+	return ts, nil
+}
@@ -0,0 +1,69 @@
+// style.go
+//
+// severity styling — attachment color bars and header emoji so criticals
+// stand out when scanning a channel.
+
+package slackout
+
+import (
+	"maps"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/config"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+var severityColors = map[finding.SeverityLevel]string{
+	finding.SeverityCritical: "#d62728",
+	finding.SeverityHigh:     "#ff7f0e",
+	finding.SeverityMedium:   "#f2c744",
+	finding.SeverityLow:      "#9e9e9e",
+	finding.SeverityUnknown:  "#9e9e9e",
+}
+
+var defaultSeverityEmoji = map[finding.SeverityLevel]string{
+	finding.SeverityCritical: ":rotating_light:",
+	finding.SeverityHigh:     ":large_orange_circle:",
+	finding.SeverityMedium:   ":large_yellow_circle:",
+	finding.SeverityLow:      ":white_circle:",
+}
+
+// Style decides how a finding is decorated. the zero value keeps the plain
+// block layout.
+type Style struct {
+	Color bool
+	Emoji map[finding.SeverityLevel]string
+}
+
+// NewStyle builds the style from config. the attachment style enables color
+// bars and the default emoji; APP_SEVERITY_EMOJI overrides individual emoji
+// in either style.
+func NewStyle(cfg config.Config) Style {
+	s := Style{Emoji: map[finding.SeverityLevel]string{}}
+	if cfg.SlackStyle == config.SlackStyleAttachment {
+		s.Color = true
+		maps.Copy(s.Emoji, defaultSeverityEmoji)
+	}
+	maps.Copy(s.Emoji, cfg.SeverityEmoji)
+	return s
+}
+
+// ColorFor returns the attachment color for the finding, or an empty string
+// when color bars are disabled.
+func (s Style) ColorFor(f finding.Finding) string {
+	if !s.Color {
+		return ""
+	}
+	if c, ok := severityColors[f.SeverityLabel]; ok {
+		return c
+	}
+	return severityColors[finding.SeverityUnknown]
+}
+
+// EmojiFor looks the emoji up by the finding's label, then by its level, so
+// custom labels can have their own emoji.
+func (s Style) EmojiFor(f finding.Finding) string {
+	if e, ok := s.Emoji[finding.SeverityLevel(f.SeverityName)]; ok {
+		return e
+	}
+	return s.Emoji[f.SeverityLabel]
+}
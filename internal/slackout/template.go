@@ -0,0 +1,104 @@
+// template.go
+//
+// message templates — render findings into slack block kit json via
+// text/template. the built-in layout lives in templates/default.json.tmpl.
+
+package slackout
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/config"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/i18n"
+)
+
+//go:embed templates/default.json.tmpl
+var defaultTemplate string
+
+type TemplateData struct {
+	finding.Finding
+	// Links are rendered as extra buttons next to "View in Console".
+	Links       []Link
+	RunbookHint string
+	// Details holds the malware scan and runtime sections, when present.
+	Details []Detail
+	// Emoji prefixes the header; empty unless configured.
+	Emoji string
+	// AckValue is the value of the acknowledge and resolve buttons; empty
+	// when the buttons are disabled.
+	AckValue string
+}
+
+type Link struct {
+	Label string
+	URL   string
+}
+
+type Template struct {
+	tmpl *template.Template
+}
+
+// templateFuncs are available to every template; t translates its argument
+// with the catalog of APP_LOCALE.
+func templateFuncs(cat i18n.Catalog) template.FuncMap {
+	return template.FuncMap{
+		"t": cat.T,
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}
+}
+
+func ParseTemplate(name, text string, cat i18n.Catalog) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs(cat)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", name, err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+func (t *Template) Render(data TemplateData) ([]slack.Block, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("execute template: %w", err)
+	}
+	var blocks slack.Blocks
+	if err := json.Unmarshal(buf.Bytes(), &blocks); err != nil {
+		return nil, fmt.Errorf("template output is not valid block kit json: %w", err)
+	}
+	return blocks.BlockSet, nil
+}
+
+// LoadTemplate resolves the template source in order of precedence:
+// inline APP_SLACK_TEMPLATE, then APP_SLACK_TEMPLATE_PATH (local file or
+// s3://bucket/key), then the built-in default.
+func LoadTemplate(ctx context.Context, cfg config.Config) (*Template, error) {
+	cat, err := i18n.Load(cfg.Locale)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case cfg.SlackTemplate != "":
+		return ParseTemplate("inline", cfg.SlackTemplate, cat)
+	case cfg.SlackTemplatePath != "":
+		text, err := config.ReadSource(ctx, cfg.SlackTemplatePath)
+		if err != nil {
+			return nil, err
+		}
+		return ParseTemplate(cfg.SlackTemplatePath, text, cat)
+	default:
+		return ParseTemplate("default", defaultTemplate, cat)
+	}
+}
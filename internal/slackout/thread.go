@@ -0,0 +1,126 @@
+// thread.go
+//
+// thread follow-up — after the summary is posted, reply in its thread with
+// the service.action details and the full finding json so responders don't
+// have to dig through cloudwatch logs.
+
+package slackout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+// slack rejects text objects over 3000 characters; leave room for the code
+// fence and heading.
+const threadInlineLimit = 2900
+
+func prettyJSON(raw json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+func codeSection(title, body string) *slack.SectionBlock {
+	return slack.NewSectionBlock(
+		slack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*%s*\n```%s```", title, body), false, false),
+		nil, nil,
+	)
+}
+
+// RenderActionDetails summarizes service.action. the action type is shown as a
+// heading followed by the action payload.
+func RenderActionDetails(f finding.Finding) []slack.Block {
+	if len(f.Service.Action) == 0 || string(f.Service.Action) == "null" {
+		return nil
+	}
+
+	var action map[string]json.RawMessage
+	if err := json.Unmarshal(f.Service.Action, &action); err != nil {
+		return nil
+	}
+	var actionType string
+	if raw, ok := action["actionType"]; ok {
+		json.Unmarshal(raw, &actionType)
+	}
+
+	title := "Action"
+	if actionType != "" {
+		title = "Action: " + actionType
+	}
+
+	var blocks []slack.Block
+	for _, key := range slices.Sorted(maps.Keys(action)) {
+		body := prettyJSON(action[key])
+		// oversized actions are still part of the full finding json upload
+		if key == "actionType" || len(body) > threadInlineLimit {
+			continue
+		}
+		blocks = append(blocks, codeSection(fmt.Sprintf("%s (%s)", title, key), body))
+	}
+	return blocks
+}
+
+func postThreadDetails(ctx context.Context, poster SlackPoster, channel, threadTS string, f finding.Finding) error {
+	if blocks := RenderActionDetails(f); len(blocks) > 0 {
+		if _, err := poster.PostMessage(ctx, channel, threadTS, "Finding action details", blocks); err != nil {
+			return fmt.Errorf("post action details: %w", err)
+		}
+	}
+
+	body := prettyJSON(f.Raw)
+	if len(body) <= threadInlineLimit {
+		_, err := poster.PostMessage(ctx, channel, threadTS, "Finding JSON",
+			[]slack.Block{codeSection("Finding JSON", body)},
+		)
+		if err != nil {
+			return fmt.Errorf("post finding json: %w", err)
+		}
+		return nil
+	}
+
+	filename := fmt.Sprintf("finding-%s.json", strings.ReplaceAll(f.ID, "/", "-"))
+	if err := poster.UploadFile(ctx, channel, threadTS, filename, body); err != nil {
+		return fmt.Errorf("upload finding json: %w", err)
+	}
+	return nil
+}
+
+// postOverflow replies with the parts FitBlocks cut from the summary: the
+// full text of truncated fields, then any blocks past the block limit.
+func postOverflow(ctx context.Context, poster SlackPoster, channel, threadTS string, f finding.Finding, o Overflow) error {
+	if len(o.Texts) > 0 {
+		body := strings.Join(o.Texts, "\n\n")
+		if len([]rune(body)) <= threadInlineLimit {
+			_, err := poster.PostMessage(ctx, channel, threadTS, "Full text", []slack.Block{
+				slack.NewSectionBlock(slack.NewTextBlockObject("plain_text", body, false, false), nil, nil),
+			})
+			if err != nil {
+				return fmt.Errorf("post overflow text: %w", err)
+			}
+		} else {
+			filename := fmt.Sprintf("finding-%s.txt", strings.ReplaceAll(f.ID, "/", "-"))
+			if err := poster.UploadFile(ctx, channel, threadTS, filename, body); err != nil {
+				return fmt.Errorf("upload overflow text: %w", err)
+			}
+		}
+	}
+
+	for chunk := range slices.Chunk(o.Blocks, maxBlocks) {
+		if _, err := poster.PostMessage(ctx, channel, threadTS, "Continued", chunk); err != nil {
+			return fmt.Errorf("post overflow blocks: %w", err)
+		}
+	}
+	return nil
+}
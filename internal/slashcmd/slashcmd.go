@@ -0,0 +1,234 @@
+// slashcmd.go
+//
+// /guardduty slash command — query recent findings from the guardduty api and
+// reply with an ephemeral summary.
+
+package slashcmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	gdtypes "github.com/aws/aws-sdk-go-v2/service/guardduty/types"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+const (
+	defaultWindow = 24 * time.Hour
+	defaultLimit  = 10
+	maxLimit      = 50
+)
+
+func usage(thresholds finding.Thresholds) string {
+	labels := make([]string, len(thresholds))
+	for i, th := range thresholds {
+		labels[i] = strings.ToLower(th.Label)
+	}
+	return "usage: `/guardduty [" + strings.Join(labels, "|") + "] [<account id>] [<N>h|<N>d] [limit=<N>]`\n" +
+		"shows unarchived findings at or above the severity, updated within the window " +
+		"(default: all severities, last 24h, 10 results)."
+}
+
+var (
+	accountIDRe = regexp.MustCompile(`^\d{12}$`)
+	windowRe    = regexp.MustCompile(`^(\d+)([hd])$`)
+
+	// mrkdwnEscaper escapes the characters slack treats as control sequences.
+	mrkdwnEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+type Query struct {
+	// MinSeverity is the severity label as typed; minScore the lower bound of
+	// its bucket.
+	MinSeverity string
+	minScore    float64
+	AccountID   string
+	Window      time.Duration
+	Limit       int
+}
+
+// ParseQuery reads the slash command text. tokens may appear in any order;
+// severities are the labels of thresholds or the built-in levels.
+func ParseQuery(text string, thresholds finding.Thresholds) (Query, error) {
+	q := Query{Window: defaultWindow, Limit: defaultLimit}
+	for _, tok := range strings.Fields(strings.ToLower(text)) {
+		if floor, _, ok := thresholds.Floor(tok); ok {
+			q.MinSeverity, q.minScore = tok, floor
+			continue
+		}
+		switch {
+		case accountIDRe.MatchString(tok):
+			q.AccountID = tok
+		case windowRe.MatchString(tok):
+			m := windowRe.FindStringSubmatch(tok)
+			n, _ := strconv.Atoi(m[1])
+			unit := time.Hour
+			if m[2] == "d" {
+				unit = 24 * time.Hour
+			}
+			q.Window = time.Duration(n) * unit
+		case strings.HasPrefix(tok, "limit="):
+			n, err := strconv.Atoi(strings.TrimPrefix(tok, "limit="))
+			if err != nil || n < 1 {
+				return Query{}, fmt.Errorf("invalid limit %q", tok)
+			}
+			q.Limit = min(n, maxLimit)
+		default:
+			return Query{}, fmt.Errorf("unrecognized argument %q", tok)
+		}
+	}
+	return q, nil
+}
+
+func (q Query) criteria(now time.Time) *gdtypes.FindingCriteria {
+	c := map[string]gdtypes.Condition{
+		"service.archived": {Equals: []string{"false"}},
+		"updatedAt":        {GreaterThanOrEqual: aws.Int64(now.Add(-q.Window).UnixMilli())},
+	}
+	// the api compares whole numbers, so this over-selects; query drops the
+	// findings below the bucket.
+	if q.MinSeverity != "" {
+		c["severity"] = gdtypes.Condition{
+			GreaterThanOrEqual: aws.Int64(int64(math.Floor(q.minScore))),
+		}
+	}
+	if q.AccountID != "" {
+		c["accountId"] = gdtypes.Condition{Equals: []string{q.AccountID}}
+	}
+	return &gdtypes.FindingCriteria{Criterion: c}
+}
+
+func (q Query) describe() string {
+	parts := []string{fmt.Sprintf("last %s", formatWindow(q.Window))}
+	if q.MinSeverity != "" {
+		parts = append(parts, fmt.Sprintf("severity ≥ %s", q.MinSeverity))
+	}
+	if q.AccountID != "" {
+		parts = append(parts, "account "+q.AccountID)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatWindow(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return fmt.Sprintf("%dh", d/time.Hour)
+}
+
+// ------------------------------------------------------------- commander ---
+
+type GuardDutyAPI interface {
+	ListFindings(ctx context.Context, in *guardduty.ListFindingsInput, opts ...func(*guardduty.Options)) (*guardduty.ListFindingsOutput, error)
+	GetFindings(ctx context.Context, in *guardduty.GetFindingsInput, opts ...func(*guardduty.Options)) (*guardduty.GetFindingsOutput, error)
+}
+
+type Commander struct {
+	client      GuardDutyAPI
+	detectorID  string
+	consoleBase string
+	thresholds  finding.Thresholds
+}
+
+func NewCommander(client GuardDutyAPI, detectorID, consoleBase string, thresholds finding.Thresholds) *Commander {
+	return &Commander{client: client, detectorID: detectorID, consoleBase: consoleBase, thresholds: thresholds}
+}
+
+// Run answers a slash command. usage errors are reported back to the user;
+// api errors are returned for the caller to log, as they are not for slack.
+func (c *Commander) Run(ctx context.Context, cmd slack.SlashCommand) (slack.Msg, error) {
+	if strings.TrimSpace(cmd.Text) == "help" {
+		return ephemeral(usage(c.thresholds)), nil
+	}
+	q, err := ParseQuery(cmd.Text, c.thresholds)
+	if err != nil {
+		return ephemeral(fmt.Sprintf("%s\n%s", err, usage(c.thresholds))), nil
+	}
+
+	findings, err := c.query(ctx, q, time.Now())
+	if err != nil {
+		return slack.Msg{}, err
+	}
+	return c.render(q, findings), nil
+}
+
+func (c *Commander) query(ctx context.Context, q Query, now time.Time) ([]gdtypes.Finding, error) {
+	list, err := c.client.ListFindings(ctx, &guardduty.ListFindingsInput{
+		DetectorId:      aws.String(c.detectorID),
+		FindingCriteria: q.criteria(now),
+		SortCriteria: &gdtypes.SortCriteria{
+			AttributeName: aws.String("updatedAt"),
+			OrderBy:       gdtypes.OrderByDesc,
+		},
+		MaxResults: aws.Int32(int32(q.Limit)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list findings: %w", err)
+	}
+	if len(list.FindingIds) == 0 {
+		return nil, nil
+	}
+
+	out, err := c.client.GetFindings(ctx, &guardduty.GetFindingsInput{
+		DetectorId: aws.String(c.detectorID),
+		FindingIds: list.FindingIds,
+		SortCriteria: &gdtypes.SortCriteria{
+			AttributeName: aws.String("updatedAt"),
+			OrderBy:       gdtypes.OrderByDesc,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get findings: %w", err)
+	}
+	if q.MinSeverity == "" {
+		return out.Findings, nil
+	}
+	return slices.DeleteFunc(out.Findings, func(gf gdtypes.Finding) bool {
+		return !c.thresholds.AtLeast(aws.ToFloat64(gf.Severity), q.MinSeverity)
+	}), nil
+}
+
+func (c *Commander) render(q Query, findings []gdtypes.Finding) slack.Msg {
+	summary := fmt.Sprintf("%d findings (%s)", len(findings), q.describe())
+	if len(findings) == 0 {
+		return ephemeral("No findings (" + q.describe() + ").")
+	}
+
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", "*"+summary+"*", false, false), nil, nil),
+		slack.NewDividerBlock(),
+	}
+	for _, gf := range findings {
+		_, label := c.thresholds.Classify(aws.ToFloat64(gf.Severity))
+		text := fmt.Sprintf("*%s* <%s|%s>\n`%s` · %s · %s · updated %s",
+			label,
+			finding.ConsoleURL(c.consoleBase, aws.ToString(gf.Region), aws.ToString(gf.Id)),
+			mrkdwnEscaper.Replace(aws.ToString(gf.Title)),
+			aws.ToString(gf.Type),
+			aws.ToString(gf.AccountId),
+			aws.ToString(gf.Region),
+			aws.ToString(gf.UpdatedAt),
+		)
+		blocks = append(blocks, slack.NewSectionBlock(
+			slack.NewTextBlockObject("mrkdwn", text, false, false), nil, nil,
+		))
+	}
+
+	msg := ephemeral(summary)
+	msg.Blocks = slack.Blocks{BlockSet: blocks}
+	return msg
+}
+
+func ephemeral(text string) slack.Msg {
+	return slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: text}
+}
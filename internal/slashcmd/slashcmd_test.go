@@ -0,0 +1,71 @@
+package slashcmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	gdtypes "github.com/aws/aws-sdk-go-v2/service/guardduty/types"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Query
+		wantErr bool
+	}{
+		{text: "", want: Query{Window: defaultWindow, Limit: defaultLimit}},
+		{
+			text: "High 123456789012 7d limit=5",
+			want: Query{MinSeverity: "high", minScore: 7, AccountID: "123456789012", Window: 7 * 24 * time.Hour, Limit: 5},
+		},
+		{text: "limit=500 12h", want: Query{Window: 12 * time.Hour, Limit: maxLimit}},
+		{text: "limit=0", wantErr: true},
+		{text: "severe", wantErr: true},
+		{text: "12345", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			q, err := ParseQuery(tt.text, finding.DefaultThresholds)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if q != tt.want {
+				t.Errorf("got %+v, want %+v", q, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	c := NewCommander(nil, "det", "", finding.DefaultThresholds)
+	q := Query{MinSeverity: "high", Window: 2 * 24 * time.Hour, Limit: defaultLimit}
+
+	if msg := c.render(q, nil); msg.Text != "No findings (last 2d, severity ≥ high)." || msg.ResponseType != "ephemeral" {
+		t.Errorf("empty result: got %+v", msg)
+	}
+
+	msg := c.render(q, []gdtypes.Finding{{
+		Id:        aws.String("f-1"),
+		Title:     aws.String("Port <22> scanned by A&B"),
+		Type:      aws.String("Recon:EC2/PortProbeUnprotectedPort"),
+		AccountId: aws.String("123456789012"),
+		Region:    aws.String("us-east-1"),
+		Severity:  aws.Float64(8),
+		UpdatedAt: aws.String("2024-01-01T00:00:00Z"),
+	}})
+	if msg.Text != "1 findings (last 2d, severity ≥ high)" {
+		t.Errorf("text = %q", msg.Text)
+	}
+	if len(msg.Blocks.BlockSet) != 3 {
+		t.Fatalf("got %d blocks, want a header, a divider and one finding", len(msg.Blocks.BlockSet))
+	}
+	text := msg.Blocks.BlockSet[2].(*slack.SectionBlock).Text.Text
+	if !strings.HasPrefix(text, "*high* <") || !strings.Contains(text, "fId=f-1|Port &lt;22&gt; scanned by A&amp;B>") {
+		t.Errorf("finding line = %q", text)
+	}
+}
@@ -0,0 +1,438 @@
+// summary.go
+//
+// pinned summary — one message in the channel, kept up to date with counts of
+// open findings by severity and account. findings are opened when posted and
+// closed when resolved in slack, archived in guardduty or stale.
+
+package summary
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/ack"
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+const (
+	// maxUpdateAttempts bounds retries when concurrent invocations race on the
+	// state item.
+	maxUpdateAttempts = 5
+	topAccounts       = 10
+
+	// the state is one dynamodb item, limited to 400 KB. findings that are
+	// never resolved or archived expire after maxOpenAge, and past maxOpen
+	// the oldest are dropped.
+	maxOpen    = 1500
+	maxOpenAge = 30 * 24 * time.Hour
+)
+
+// ErrConflict is returned by Store.Save when the state changed since Load.
+var ErrConflict = errors.New("summary state changed concurrently")
+
+// Item is one open finding.
+type Item struct {
+	Level        finding.SeverityLevel `json:"l"`
+	Severity     string                `json:"s"`
+	Account      string                `json:"a"`
+	Acknowledged bool                  `json:"k,omitempty"`
+	// Opened is when the finding was first posted, in unix seconds.
+	Opened int64 `json:"o"`
+}
+
+type State struct {
+	// Open is keyed by ack.Ref.Key.
+	Open      map[string]Item `json:"open"`
+	MessageTS string          `json:"ts,omitempty"`
+
+	version int64
+}
+
+// prune drops findings open longer than maxOpenAge, then the oldest past
+// maxOpen, and returns how many it dropped.
+func (st *State) prune(now time.Time) int {
+	before := len(st.Open)
+	maps.DeleteFunc(st.Open, func(_ string, item Item) bool {
+		return now.Sub(time.Unix(item.Opened, 0)) > maxOpenAge
+	})
+	if n := len(st.Open) - maxOpen; n > 0 {
+		keys := slices.SortedFunc(maps.Keys(st.Open), func(a, b string) int {
+			return cmp.Or(cmp.Compare(st.Open[a].Opened, st.Open[b].Opened), cmp.Compare(a, b))
+		})
+		for _, k := range keys[:n] {
+			delete(st.Open, k)
+		}
+	}
+	return before - len(st.Open)
+}
+
+type Store interface {
+	Load(ctx context.Context) (State, error)
+	// Save writes s unless another writer saved since s was loaded.
+	Save(ctx context.Context, s State) error
+}
+
+// -------------------------------------------------------------- dynamodb ---
+
+// DynamoDB keeps the state in a single item of a table with a string
+// partition key named "id". a conditional version check serializes writers.
+type DynamoDB struct {
+	client *dynamodb.Client
+	table  string
+	key    string
+}
+
+func NewDynamoDB(client *dynamodb.Client, table, channel string) *DynamoDB {
+	return &DynamoDB{client: client, table: table, key: "summary/" + channel}
+}
+
+func (d *DynamoDB) Load(ctx context.Context) (State, error) {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            map[string]ddbtypes.AttributeValue{"id": &ddbtypes.AttributeValueMemberS{Value: d.key}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return State{}, err
+	}
+	var s State
+	if raw, ok := out.Item["state"].(*ddbtypes.AttributeValueMemberS); ok {
+		if err := json.Unmarshal([]byte(raw.Value), &s); err != nil {
+			return State{}, fmt.Errorf("decode summary state: %w", err)
+		}
+	}
+	if v, ok := out.Item["version"].(*ddbtypes.AttributeValueMemberN); ok {
+		s.version, _ = strconv.ParseInt(v.Value, 10, 64)
+	}
+	return s, nil
+}
+
+func (d *DynamoDB) Save(ctx context.Context, s State) error {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item: map[string]ddbtypes.AttributeValue{
+			"id":      &ddbtypes.AttributeValueMemberS{Value: d.key},
+			"state":   &ddbtypes.AttributeValueMemberS{Value: string(raw)},
+			"version": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(s.version+1, 10)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(id) OR version = :v"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":v": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(s.version, 10)},
+		},
+	})
+	var condErr *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return ErrConflict
+	}
+	return err
+}
+
+// --------------------------------------------------------------- summary ---
+
+// SlackAPI is the slice of the slack api used to maintain the message.
+type SlackAPI interface {
+	PostMessageContext(ctx context.Context, channelID string, options ...slack.MsgOption) (string, string, error)
+	UpdateMessageContext(ctx context.Context, channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error)
+	AddPinContext(ctx context.Context, channel string, item slack.ItemRef) error
+}
+
+type Summary struct {
+	log     *slog.Logger
+	store   Store
+	api     SlackAPI
+	channel string
+	// order lists severity labels from the highest bucket down.
+	order []string
+
+	// mu serializes state updates from one container; the store handles the
+	// rest. slack is never called while it is held.
+	mu sync.Mutex
+
+	// pubMu guards the single publisher: while one call publishes, the others
+	// leave their state in pending and return.
+	pubMu      sync.Mutex
+	publishing bool
+	pending    *State
+	// ts is the message this container last posted and replaced the one it
+	// stood in for, so states loaded before it was recorded still find it.
+	ts, replaced string
+}
+
+func New(log *slog.Logger, store Store, api SlackAPI, channel string, thresholds finding.Thresholds) *Summary {
+	order := make([]string, 0, len(thresholds))
+	for _, th := range slices.Backward(thresholds) {
+		order = append(order, th.Label)
+	}
+	return &Summary{log: log, store: store, api: api, channel: channel, order: order}
+}
+
+// Track opens a posted finding, or closes it when guardduty archived it.
+func (s *Summary) Track(ctx context.Context, f finding.Finding) error {
+	key := ack.NewRef(f).Key()
+	return s.update(ctx, func(st *State) bool {
+		if f.Service.Archived {
+			_, ok := st.Open[key]
+			delete(st.Open, key)
+			return ok
+		}
+		item := Item{Level: f.SeverityLabel, Severity: f.SeverityName, Account: f.AccountDisplay()}
+		prev, ok := st.Open[key]
+		item.Acknowledged = prev.Acknowledged
+		item.Opened = prev.Opened
+		if !ok {
+			item.Opened = time.Now().Unix()
+		}
+		st.Open[key] = item
+		return !ok || prev != item
+	})
+}
+
+// StatusChanged implements ack.Observer: acknowledged findings stay open,
+// resolved ones are closed.
+func (s *Summary) StatusChanged(ctx context.Context, rec ack.Record) error {
+	key := rec.Key()
+	return s.update(ctx, func(st *State) bool {
+		item, ok := st.Open[key]
+		if !ok {
+			return false
+		}
+		if rec.Status == ack.StatusResolved {
+			delete(st.Open, key)
+		} else {
+			item.Acknowledged = true
+			st.Open[key] = item
+		}
+		return true
+	})
+}
+
+// Refresh re-renders the message, e.g. from the scheduled invocation, so the
+// timestamp stays current and a deleted message is recreated.
+func (s *Summary) Refresh(ctx context.Context) error {
+	return s.update(ctx, func(*State) bool { return true })
+}
+
+// update applies change to the stored state and, when it reports a change,
+// re-renders the pinned message.
+func (s *Summary) update(ctx context.Context, change func(*State) bool) error {
+	st, changed, err := s.apply(ctx, change)
+	if err != nil || !changed {
+		return err
+	}
+	return s.publishLatest(ctx, st)
+}
+
+// apply loads the state, changes it and saves it, retrying when another
+// container saved in between.
+func (s *Summary) apply(ctx context.Context, change func(*State) bool) (State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		st, err := s.store.Load(ctx)
+		if err != nil {
+			return State{}, false, fmt.Errorf("load summary: %w", err)
+		}
+		if st.Open == nil {
+			st.Open = map[string]Item{}
+		}
+		changed := change(&st)
+		if n := st.prune(time.Now()); n > 0 {
+			s.log.Info("dropped stale findings from the summary", "dropped", n)
+			changed = true
+		}
+		if !changed {
+			return st, false, nil
+		}
+
+		err = s.store.Save(ctx, st)
+		if errors.Is(err, ErrConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return State{}, false, fmt.Errorf("save summary: %w", err)
+		}
+		st.version++
+		return st, true, nil
+	}
+}
+
+// publishLatest renders st, or a newer state saved while it was publishing.
+// only one call publishes at a time; the others hand it their state and
+// return, so a slow slack call never holds up tracking.
+func (s *Summary) publishLatest(ctx context.Context, st State) error {
+	s.pubMu.Lock()
+	if s.publishing {
+		if s.pending == nil || st.version >= s.pending.version {
+			s.pending = &st
+		}
+		s.pubMu.Unlock()
+		return nil
+	}
+	s.publishing = true
+	s.pubMu.Unlock()
+
+	var errs []error
+	for {
+		if err := s.publish(ctx, &st); err != nil {
+			errs = append(errs, err)
+		}
+
+		s.pubMu.Lock()
+		next := s.pending
+		s.pending = nil
+		if next == nil {
+			s.publishing = false
+			s.pubMu.Unlock()
+			return errors.Join(errs...)
+		}
+		s.pubMu.Unlock()
+		st = *next
+	}
+}
+
+// recordMessage saves the ts of a newly posted message in place of stale,
+// the deleted one the post replaced, if any.
+func (s *Summary) recordMessage(ctx context.Context, stale, ts string) error {
+	_, _, err := s.apply(ctx, func(st *State) bool {
+		if st.MessageTS != "" && st.MessageTS != stale {
+			// another container posted one too; theirs is kept.
+			s.log.Warn("summary message posted concurrently", "channel", s.channel, "ts", ts)
+			return false
+		}
+		st.MessageTS = ts
+		return true
+	})
+	return err
+}
+
+// publish edits the pinned message, posting and pinning a new one when there
+// is none yet or it was deleted. it is only called by the single publisher.
+func (s *Summary) publish(ctx context.Context, st *State) error {
+	if st.MessageTS == "" || st.MessageTS == s.replaced {
+		// posted here, but loaded before the state recorded it.
+		st.MessageTS = s.ts
+	}
+	opts := []slack.MsgOption{
+		slack.MsgOptionText(fmt.Sprintf("GuardDuty: %d open findings", len(st.Open)), false),
+		slack.MsgOptionBlocks(s.Render(*st, time.Now())...),
+	}
+	if st.MessageTS != "" {
+		_, _, _, err := s.api.UpdateMessageContext(ctx, s.channel, st.MessageTS, opts...)
+		if err == nil {
+			return nil
+		}
+		var resp slack.SlackErrorResponse
+		if !errors.As(err, &resp) || resp.Err != "message_not_found" {
+			return fmt.Errorf("update summary message: %w", err)
+		}
+		s.log.Warn("summary message deleted, posting a new one", "channel", s.channel)
+	}
+
+	_, ts, err := s.api.PostMessageContext(ctx, s.channel, opts...)
+	if err != nil {
+		return fmt.Errorf("post summary message: %w", err)
+	}
+	stale := st.MessageTS
+	s.ts, s.replaced = ts, stale
+	st.MessageTS = ts
+	if err := s.api.AddPinContext(ctx, s.channel, slack.NewRefToMessage(s.channel, ts)); err != nil {
+		// an unpinned summary is still useful.
+		s.log.Warn("failed to pin summary message", "channel", s.channel, "error", err)
+	}
+	if err := s.recordMessage(ctx, stale, ts); err != nil {
+		return fmt.Errorf("record summary message: %w", err)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------- render ---
+
+func (s *Summary) Render(st State, now time.Time) []slack.Block {
+	type count struct{ open, acked int }
+	bySeverity := map[string]*count{}
+	byAccount := map[string]int{}
+	acked := 0
+	for _, item := range st.Open {
+		c := bySeverity[item.Severity]
+		if c == nil {
+			c = &count{}
+			bySeverity[item.Severity] = c
+		}
+		c.open++
+		if item.Acknowledged {
+			c.acked++
+			acked++
+		}
+		byAccount[item.Account]++
+	}
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, ":bar_chart: GuardDuty open findings", true, false)),
+	}
+
+	// configured labels first, highest bucket first, then anything left over
+	// from an older threshold config.
+	labels := slices.Clone(s.order)
+	for _, l := range slices.Sorted(maps.Keys(bySeverity)) {
+		if !slices.Contains(labels, l) {
+			labels = append(labels, l)
+		}
+	}
+	var fields []*slack.TextBlockObject
+	for _, l := range labels {
+		c := bySeverity[l]
+		if c == nil {
+			c = &count{}
+		}
+		text := fmt.Sprintf("*%s:* %d", l, c.open)
+		if c.acked > 0 {
+			text += fmt.Sprintf(" (%d acked)", c.acked)
+		}
+		fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, text, false, false))
+	}
+	for chunk := range slices.Chunk(fields, 10) {
+		blocks = append(blocks, slack.NewSectionBlock(nil, chunk, nil))
+	}
+
+	if len(byAccount) > 0 {
+		accounts := slices.SortedFunc(maps.Keys(byAccount), func(a, b string) int {
+			return cmp.Or(cmp.Compare(byAccount[b], byAccount[a]), cmp.Compare(a, b))
+		})
+		var b strings.Builder
+		b.WriteString("*By account*")
+		for _, a := range accounts[:min(len(accounts), topAccounts)] {
+			fmt.Fprintf(&b, "\n• %s — %d", a, byAccount[a])
+		}
+		if n := len(accounts) - topAccounts; n > 0 {
+			fmt.Fprintf(&b, "\n…and %d more accounts", n)
+		}
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, b.String(), false, false), nil, nil))
+	}
+
+	blocks = append(blocks, slack.NewContextBlock("updated", slack.NewTextBlockObject(slack.MarkdownType,
+		fmt.Sprintf("%d open · %d acknowledged · updated <!date^%d^{date_short_pretty} {time}|%s>",
+			len(st.Open), acked, now.Unix(), now.UTC().Format("2006-01-02 15:04 UTC")),
+		false, false,
+	)))
+	return blocks
+}
@@ -0,0 +1,136 @@
+package summary
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+func TestStatePrune(t *testing.T) {
+	now := time.Now()
+	st := State{Open: map[string]Item{
+		"stale": {Opened: now.Add(-maxOpenAge - time.Hour).Unix()},
+	}}
+	for i := range maxOpen + 5 {
+		st.Open[fmt.Sprintf("f%04d", i)] = Item{Opened: now.Add(time.Duration(i-maxOpen) * time.Minute).Unix()}
+	}
+
+	if n := st.prune(now); n != 6 {
+		t.Errorf("pruned %d findings, want 6", n)
+	}
+	if len(st.Open) != maxOpen {
+		t.Errorf("%d findings left, want %d", len(st.Open), maxOpen)
+	}
+	for _, k := range []string{"stale", "f0000", "f0004"} {
+		if _, ok := st.Open[k]; ok {
+			t.Errorf("%s was kept", k)
+		}
+	}
+	if _, ok := st.Open["f0005"]; !ok {
+		t.Error("f0005 was dropped")
+	}
+}
+
+type memoryStore struct {
+	mu sync.Mutex
+	st State
+}
+
+func (m *memoryStore) Load(context.Context) (State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.st
+	st.Open = maps.Clone(m.st.Open)
+	return st, nil
+}
+
+func (m *memoryStore) Save(_ context.Context, st State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if st.version != m.st.version {
+		return ErrConflict
+	}
+	st.version++
+	m.st = st
+	return nil
+}
+
+// blockingSlack holds every message update until release is closed.
+type blockingSlack struct {
+	release chan struct{}
+	started chan struct{}
+
+	mu       sync.Mutex
+	posts    int
+	lastText string
+}
+
+func (b *blockingSlack) PostMessageContext(_ context.Context, _ string, opts ...slack.MsgOption) (string, string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.posts++
+	return "C1", "1700000000.000001", nil
+}
+
+func (b *blockingSlack) UpdateMessageContext(_ context.Context, _, _ string, opts ...slack.MsgOption) (string, string, string, error) {
+	b.started <- struct{}{}
+	<-b.release
+	_, values, _ := slack.UnsafeApplyMsgOptions("", "C1", "", opts...)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastText = values.Get("text")
+	return "C1", "1700000000.000001", "", nil
+}
+
+func (b *blockingSlack) AddPinContext(context.Context, string, slack.ItemRef) error {
+	return nil
+}
+
+func TestSummaryTrackDuringSlowPublish(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{st: State{Open: map[string]Item{}, MessageTS: "1700000000.000001"}}
+	api := &blockingSlack{release: make(chan struct{}), started: make(chan struct{}, 3)}
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)), store, api, "C1", finding.DefaultThresholds)
+	track := func(id string) error {
+		return s.Track(ctx, finding.Finding{ID: id, Source: finding.SourceGuardDuty, SeverityLabel: finding.SeverityHigh, SeverityName: "high"})
+	}
+
+	done := make(chan error)
+	go func() { done <- track("f1") }()
+	<-api.started
+
+	// the first publish is stuck in slack; tracking must go on regardless.
+	for _, id := range []string{"f2", "f3"} {
+		tracked := make(chan error)
+		go func() { tracked <- track(id) }()
+		select {
+		case err := <-tracked:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("tracking %s waited for slack", id)
+		}
+	}
+	if st, _ := store.Load(ctx); len(st.Open) != 3 {
+		t.Errorf("stored %d open findings, want 3", len(st.Open))
+	}
+
+	close(api.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// the newest state is published once the slow call returns.
+	if api.lastText != "GuardDuty: 3 open findings" || api.posts != 0 {
+		t.Errorf("last published %q with %d posts, want 3 open findings and no new message", api.lastText, api.posts)
+	}
+}
@@ -0,0 +1,348 @@
+// flood.go
+//
+// flood control — findings are counted in fixed windows. past the threshold
+// they stop posting individually and are folded into one "storm" message per
+// window, edited in place with the running count by type.
+
+package throttle
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+// DefaultWindow is used when APP_FLOOD_WINDOW is not set.
+const DefaultWindow = 5 * time.Minute
+
+const topTypes = 10
+
+// Window is the tally of one window.
+type Window struct {
+	Start     time.Time
+	Count     int
+	Types     map[string]int
+	MessageTS string
+}
+
+type Store interface {
+	// Add counts f in the window beginning at start and returns the window
+	// including it.
+	Add(ctx context.Context, start time.Time, f finding.Finding) (Window, error)
+	// ClaimMessage reserves posting the window's storm message, reporting
+	// false while another invocation holds it or the message is posted.
+	ClaimMessage(ctx context.Context, start time.Time) (bool, error)
+	// SetMessage records the storm message posted for the window.
+	SetMessage(ctx context.Context, start time.Time, ts string) error
+	// ReleaseMessage drops the claim after the storm message failed to post.
+	ReleaseMessage(ctx context.Context, start time.Time) error
+}
+
+// ---------------------------------------------------------------- memory ---
+
+// Memory counts per container, which is enough for a single concurrent
+// invocation; use DynamoDB when bursts fan out across containers.
+type Memory struct {
+	mu      sync.Mutex
+	windows map[time.Time]*Window
+	claimed map[time.Time]bool
+}
+
+func NewMemory() *Memory {
+	return &Memory{windows: map[time.Time]*Window{}, claimed: map[time.Time]bool{}}
+}
+
+func (m *Memory) Add(_ context.Context, start time.Time, f finding.Finding) (Window, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := m.windows[start]
+	if w == nil {
+		// only the current window is ever added to.
+		clear(m.windows)
+		clear(m.claimed)
+		w = &Window{Start: start, Types: map[string]int{}}
+		m.windows[start] = w
+	}
+	w.Count++
+	w.Types[f.Type]++
+	out := *w
+	out.Types = maps.Clone(w.Types)
+	return out, nil
+}
+
+func (m *Memory) ClaimMessage(_ context.Context, start time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.claimed[start] {
+		return false, nil
+	}
+	m.claimed[start] = true
+	return true, nil
+}
+
+func (m *Memory) ReleaseMessage(_ context.Context, start time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.claimed, start)
+	return nil
+}
+
+func (m *Memory) SetMessage(_ context.Context, start time.Time, ts string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if w := m.windows[start]; w != nil {
+		w.MessageTS = ts
+	}
+	return nil
+}
+
+// -------------------------------------------------------------- dynamodb ---
+
+// DynamoDB counts with atomic adds on one item per channel and window. the
+// table needs a string partition key named "id"; enable ttl on "expiresAt".
+type DynamoDB struct {
+	client  *dynamodb.Client
+	table   string
+	channel string
+	ttl     time.Duration
+}
+
+// typePrefix marks the per-type counters among the item's attributes.
+const typePrefix = "type:"
+
+func NewDynamoDB(client *dynamodb.Client, table, channel string, window time.Duration) *DynamoDB {
+	return &DynamoDB{client: client, table: table, channel: channel, ttl: 2 * window}
+}
+
+func (d *DynamoDB) key(start time.Time) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		"id": &ddbtypes.AttributeValueMemberS{Value: "flood/" + d.channel + "/" + strconv.FormatInt(start.Unix(), 10)},
+	}
+}
+
+func (d *DynamoDB) Add(ctx context.Context, start time.Time, f finding.Finding) (Window, error) {
+	out, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(d.table),
+		Key:              d.key(start),
+		UpdateExpression: aws.String("SET expiresAt = :exp ADD #count :one, #type :one"),
+		ExpressionAttributeNames: map[string]string{
+			"#count": "count",
+			"#type":  typePrefix + f.Type,
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":exp": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(start.Add(d.ttl).Unix(), 10)},
+			":one": &ddbtypes.AttributeValueMemberN{Value: "1"},
+		},
+		ReturnValues: ddbtypes.ReturnValueAllNew,
+	})
+	if err != nil {
+		return Window{}, err
+	}
+
+	w := Window{Start: start, Types: map[string]int{}}
+	for name, v := range out.Attributes {
+		switch v := v.(type) {
+		case *ddbtypes.AttributeValueMemberN:
+			n, _ := strconv.Atoi(v.Value)
+			if name == "count" {
+				w.Count = n
+			} else if t, ok := strings.CutPrefix(name, typePrefix); ok {
+				w.Types[t] = n
+			}
+		case *ddbtypes.AttributeValueMemberS:
+			if name == "ts" {
+				w.MessageTS = v.Value
+			}
+		}
+	}
+	return w, nil
+}
+
+// ClaimMessage marks the window's item as posting; the mark stays once the
+// message is posted, so only one storm message is ever posted per window.
+func (d *DynamoDB) ClaimMessage(ctx context.Context, start time.Time) (bool, error) {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 d.key(start),
+		UpdateExpression:    aws.String("SET posting = :true"),
+		ConditionExpression: aws.String("attribute_not_exists(posting)"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":true": &ddbtypes.AttributeValueMemberBOOL{Value: true},
+		},
+	})
+	var condErr *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (d *DynamoDB) ReleaseMessage(ctx context.Context, start time.Time) error {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(d.table),
+		Key:              d.key(start),
+		UpdateExpression: aws.String("REMOVE posting"),
+	})
+	return err
+}
+
+func (d *DynamoDB) SetMessage(ctx context.Context, start time.Time, ts string) error {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(d.table),
+		Key:              d.key(start),
+		UpdateExpression: aws.String("SET ts = :ts"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":ts": &ddbtypes.AttributeValueMemberS{Value: ts},
+		},
+	})
+	return err
+}
+
+// ----------------------------------------------------------------- flood ---
+
+// SlackAPI is the slice of the slack api used to maintain the storm message.
+type SlackAPI interface {
+	PostMessageContext(ctx context.Context, channelID string, options ...slack.MsgOption) (string, string, error)
+	UpdateMessageContext(ctx context.Context, channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error)
+}
+
+type Flood struct {
+	log       *slog.Logger
+	store     Store
+	api       SlackAPI
+	channel   string
+	threshold int
+	window    time.Duration
+}
+
+func NewFlood(log *slog.Logger, store Store, api SlackAPI, channel string, threshold int, window time.Duration) *Flood {
+	return &Flood{log: log, store: store, api: api, channel: channel, threshold: threshold, window: window}
+}
+
+// Admit counts f and reports whether it may be posted on its own. past the
+// threshold it is folded into the window's storm message instead: the first
+// one to claim the message posts it, later ones edit it. an error means f was
+// not folded into a posted message, so the caller should post f itself.
+func (fl *Flood) Admit(ctx context.Context, f finding.Finding) (bool, error) {
+	start := time.Now().Truncate(fl.window)
+	w, err := fl.store.Add(ctx, start, f)
+	if err != nil {
+		return true, fmt.Errorf("count finding: %w", err)
+	}
+	if w.Count <= fl.threshold {
+		return true, nil
+	}
+
+	opts := []slack.MsgOption{
+		slack.MsgOptionText(fmt.Sprintf("GuardDuty finding storm: %d findings", w.Count), false),
+		slack.MsgOptionBlocks(fl.Render(w, time.Now())...),
+	}
+	if w.MessageTS != "" {
+		// a lost edit is corrected by the next one.
+		if _, _, _, err := fl.api.UpdateMessageContext(ctx, fl.channel, w.MessageTS, opts...); err != nil {
+			fl.log.Warn("failed to update storm message", "error", err)
+		}
+		return false, nil
+	}
+
+	// a failed post leaves the message to the next finding over the threshold.
+	claimed, err := fl.store.ClaimMessage(ctx, start)
+	if err != nil {
+		return true, fmt.Errorf("claim storm message: %w", err)
+	}
+	if !claimed {
+		return true, errors.New("storm message is not posted yet")
+	}
+	_, ts, err := fl.api.PostMessageContext(ctx, fl.channel, opts...)
+	if err != nil {
+		if err := fl.store.ReleaseMessage(ctx, start); err != nil {
+			fl.log.Warn("failed to release storm message", "error", err)
+		}
+		return true, fmt.Errorf("post storm message: %w", err)
+	}
+	if err := fl.store.SetMessage(ctx, start, ts); err != nil {
+		fl.log.Warn("failed to record storm message", "error", err)
+	}
+	return false, nil
+}
+
+func (fl *Flood) Render(w Window, now time.Time) []slack.Block {
+	collapsed := w.Count - fl.threshold
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, ":ocean: GuardDuty finding storm", true, false)),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf(
+			"*%d findings* since <!date^%d^{time}|%s>. The first %d were posted; the other %d are collapsed here.",
+			w.Count, w.Start.Unix(), w.Start.UTC().Format("15:04 UTC"), fl.threshold, collapsed,
+		), false, false), nil, nil),
+	}
+
+	types := slices.SortedFunc(maps.Keys(w.Types), func(a, b string) int {
+		return cmp.Or(cmp.Compare(w.Types[b], w.Types[a]), cmp.Compare(a, b))
+	})
+	var b strings.Builder
+	b.WriteString("*By type*")
+	for _, t := range types[:min(len(types), topTypes)] {
+		fmt.Fprintf(&b, "\n• `%s` — %d", t, w.Types[t])
+	}
+	if n := len(types) - topTypes; n > 0 {
+		fmt.Fprintf(&b, "\n…and %d more types", n)
+	}
+	blocks = append(blocks,
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, b.String(), false, false), nil, nil),
+		slack.NewContextBlock("updated", slack.NewTextBlockObject(slack.MarkdownType,
+			fmt.Sprintf("window of %s · updated <!date^%d^{time}|%s>", fl.window, now.Unix(), now.UTC().Format("15:04 UTC")),
+			false, false,
+		)),
+	)
+	return blocks
+}
+
+// -------------------------------------------------------------------- floods ---
+
+// Floods keeps one Flood per channel, created on first use, so each channel
+// counts its own findings and gets its own storm message.
+type Floods struct {
+	mu        sync.Mutex
+	floods    map[string]*Flood
+	newStore  func(channel string) Store
+	threshold int
+	window    time.Duration
+}
+
+// NewFloods counts with a store from newStore per channel.
+func NewFloods(newStore func(channel string) Store, threshold int, window time.Duration) *Floods {
+	return &Floods{floods: map[string]*Flood{}, newStore: newStore, threshold: threshold, window: window}
+}
+
+// For returns the flood control of channel. api posts its storm message, so
+// it must belong to the channel's workspace.
+func (fs *Floods) For(log *slog.Logger, api SlackAPI, channel string) *Flood {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fl, ok := fs.floods[channel]
+	if !ok {
+		fl = NewFlood(log, fs.newStore(channel), api, channel, fs.threshold, fs.window)
+		fs.floods[channel] = fl
+	}
+	return fl
+}
@@ -0,0 +1,114 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+type fakeSlack struct {
+	posts, updates int
+	// failPosts fails that many posts before succeeding.
+	failPosts int
+	// channels lists the channel of each post.
+	channels []string
+}
+
+func (f *fakeSlack) PostMessageContext(_ context.Context, channel string, _ ...slack.MsgOption) (string, string, error) {
+	f.posts++
+	f.channels = append(f.channels, channel)
+	if f.failPosts > 0 {
+		f.failPosts--
+		return "", "", errors.New("internal_error")
+	}
+	return "C1", "1700000000.000100", nil
+}
+
+func (f *fakeSlack) UpdateMessageContext(context.Context, string, string, ...slack.MsgOption) (string, string, string, error) {
+	f.updates++
+	return "C1", "1700000000.000100", "", nil
+}
+
+func TestFloodAdmit(t *testing.T) {
+	api := &fakeSlack{}
+	fl := NewFlood(slog.New(slog.NewTextHandler(io.Discard, nil)), NewMemory(), api, "C1", 3, time.Hour)
+
+	var admitted int
+	for range 10 {
+		ok, err := fl.Admit(context.Background(), finding.Finding{Type: "Recon:EC2/Portscan"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			admitted++
+		}
+	}
+	if admitted != 3 {
+		t.Errorf("admitted %d findings, want 3", admitted)
+	}
+	// one storm message, edited for each finding after the one that opened it.
+	if api.posts != 1 || api.updates != 6 {
+		t.Errorf("posts = %d, updates = %d, want 1 and 6", api.posts, api.updates)
+	}
+}
+
+func TestFloodAdmitPostFailure(t *testing.T) {
+	api := &fakeSlack{failPosts: 1}
+	fl := NewFlood(slog.New(slog.NewTextHandler(io.Discard, nil)), NewMemory(), api, "C1", 1, time.Hour)
+	admit := func() (bool, error) {
+		return fl.Admit(context.Background(), finding.Finding{Type: "Recon:EC2/Portscan"})
+	}
+
+	if ok, err := admit(); !ok || err != nil {
+		t.Fatalf("first finding: admitted %v, err %v", ok, err)
+	}
+	// the storm message fails to post, so the finding must be posted on its own.
+	if ok, err := admit(); !ok || err == nil {
+		t.Fatalf("failed storm post: admitted %v, err %v; want admitted with an error", ok, err)
+	}
+	// the next finding posts the storm message instead of being dropped.
+	if ok, err := admit(); ok || err != nil {
+		t.Fatalf("retried storm post: admitted %v, err %v; want folded", ok, err)
+	}
+	if ok, _ := admit(); ok {
+		t.Fatal("finding after the storm message was not folded")
+	}
+	if api.posts != 2 || api.updates != 1 {
+		t.Errorf("posts = %d, updates = %d, want 2 and 1", api.posts, api.updates)
+	}
+}
+
+func TestFloodsPerChannel(t *testing.T) {
+	api := &fakeSlack{}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	floods := NewFloods(func(string) Store { return NewMemory() }, 2, time.Hour)
+
+	// a routed storm in C1 must not fold the findings routed to C2.
+	admitted := map[string]int{}
+	for _, channel := range []string{"C1", "C1", "C1", "C1", "C2", "C2"} {
+		ok, err := floods.For(log, api, channel).Admit(context.Background(), finding.Finding{Type: "Recon:EC2/Portscan"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			admitted[channel]++
+		}
+	}
+	if admitted["C1"] != 2 || admitted["C2"] != 2 {
+		t.Errorf("admitted %v, want 2 in each channel", admitted)
+	}
+	if !slices.Equal(api.channels, []string{"C1"}) {
+		t.Errorf("storm messages posted to %v, want only C1", api.channels)
+	}
+	if floods.For(log, api, "C1") != floods.For(log, api, "C1") {
+		t.Error("a channel should keep its flood control")
+	}
+}
@@ -0,0 +1,81 @@
+// quiet.go
+//
+// quiet hours — a daily time-of-day range during which only findings at or
+// above a severity post immediately; the rest wait for the next digest.
+
+package throttle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours is a daily range in Location. End before Start wraps past
+// midnight. the zero value is never quiet.
+type QuietHours struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// ParseQuietHours parses "22:00-07:00" in the named time zone, UTC when tz is
+// empty.
+func ParseQuietHours(spec, tz string) (QuietHours, error) {
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("%q is not a range like 22:00-07:00", spec)
+	}
+	var (
+		q   QuietHours
+		err error
+	)
+	if q.Start, err = parseClock(from); err != nil {
+		return QuietHours{}, err
+	}
+	if q.End, err = parseClock(to); err != nil {
+		return QuietHours{}, err
+	}
+	if q.Start == q.End {
+		return QuietHours{}, fmt.Errorf("%q is empty", spec)
+	}
+	if q.Location, err = time.LoadLocation(tz); err != nil {
+		return QuietHours{}, err
+	}
+	return q, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time like 07:00", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (q QuietHours) IsZero() bool {
+	return q.Location == nil
+}
+
+// Contains reports whether t falls within the quiet hours.
+func (q QuietHours) Contains(t time.Time) bool {
+	if q.IsZero() {
+		return false
+	}
+	t = t.In(q.Location)
+	since := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if q.Start < q.End {
+		return since >= q.Start && since < q.End
+	}
+	return since >= q.Start || since < q.End
+}
+
+func (q QuietHours) String() string {
+	if q.IsZero() {
+		return ""
+	}
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(q.Start) + "-" + clock(q.End) + " " + q.Location.String()
+}
@@ -0,0 +1,42 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursContains(t *testing.T) {
+	tests := []struct {
+		spec string
+		at   string
+		want bool
+	}{
+		{"22:00-07:00", "23:30", true},
+		{"22:00-07:00", "06:59", true},
+		{"22:00-07:00", "07:00", false},
+		{"22:00-07:00", "12:00", false},
+		{"12:00-13:30", "13:00", true},
+		{"12:00-13:30", "11:59", false},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuietHours(tt.spec, "Europe/Berlin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		at, _ := time.ParseInLocation("2006-01-02 15:04", "2026-01-15 "+tt.at, q.Location)
+		if got := q.Contains(at.UTC()); got != tt.want {
+			t.Errorf("%s at %s: got %v, want %v", tt.spec, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuietHoursInvalid(t *testing.T) {
+	for _, spec := range []string{"22:00", "22:00-25:00", "07:00-07:00", "late-early"} {
+		if _, err := ParseQuietHours(spec, ""); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+	if _, err := ParseQuietHours("22:00-07:00", "Mars/Olympus"); err == nil {
+		t.Error("unknown time zone: expected an error")
+	}
+}
@@ -0,0 +1,154 @@
+// tracing.go
+//
+// opentelemetry tracing — spans for each stage of handling an event, exported
+// over otlp/http to a collector (e.g. the adot lambda layer) that forwards them
+// to x-ray. disabled, the global no-op provider makes every span free.
+
+package tracing
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cruxstack/aws-guardduty-slack-integration-go/internal/finding"
+)
+
+// ServiceName is reported unless OTEL_SERVICE_NAME overrides it.
+const ServiceName = "guardduty-slack"
+
+const instrumentation = "github.com/cruxstack/aws-guardduty-slack-integration-go"
+
+// xrayHeader is where the x-ray propagator reads the trace header from.
+const xrayHeader = "X-Amzn-Trace-Id"
+
+// Tracer returns the tracer used for all spans. it resolves the global
+// provider on each call, so spans started before Setup are simply dropped.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Provider flushes and shuts down the installed tracer provider. a nil
+// Provider, returned when tracing is disabled, does nothing.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+// Setup installs a global tracer provider exporting to the otlp endpoint from
+// the standard OTEL_EXPORTER_OTLP_* variables, or to a collector on
+// http://localhost:4318 when none is set. trace ids are x-ray compatible and
+// the lambda's x-ray sampling decision is honoured through the parent.
+func Setup(ctx context.Context) (*Provider, error) {
+	var opts []otlptracehttp.Option
+	// the collector extension listens on plain http inside the sandbox.
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// later detectors win, so OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+	// override the defaults.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", ServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithIDGenerator(xray.NewIDGenerator()),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, xray.Propagator{},
+	))
+	return &Provider{tp: tp}, nil
+}
+
+// Flush exports buffered spans. lambda freezes the process between
+// invocations, so call it before returning from each one.
+func (p *Provider) Flush(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	return p.tp.ForceFlush(ctx)
+}
+
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	return p.tp.Shutdown(ctx)
+}
+
+// FromLambda parents ctx on the invocation's x-ray segment. the go runtime
+// sets _X_AMZN_TRACE_ID per invocation when active tracing is on.
+func FromLambda(ctx context.Context) context.Context {
+	header := os.Getenv("_X_AMZN_TRACE_ID")
+	if header == "" {
+		return ctx
+	}
+	return xray.Propagator{}.Extract(ctx, propagation.MapCarrier{xrayHeader: header})
+}
+
+// End records err on span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// EventAttributes identify the eventbridge event. event.time is when the
+// producing service published it, so its distance to the span start is the
+// delivery delay.
+func EventAttributes(evt events.CloudWatchEvent) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("event.id", evt.ID),
+		attribute.String("event.source", evt.Source),
+		attribute.String("event.detail_type", evt.DetailType),
+		attribute.String("cloud.account.id", evt.AccountID),
+		attribute.String("cloud.region", evt.Region),
+	}
+	if !evt.Time.IsZero() {
+		attrs = append(attrs,
+			attribute.String("event.time", evt.Time.UTC().Format(time.RFC3339)),
+			attribute.Int64("event.delay_ms", time.Since(evt.Time).Milliseconds()),
+		)
+	}
+	return attrs
+}
+
+// FindingAttributes describe the parsed finding.
+func FindingAttributes(f finding.Finding) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("finding.id", f.ID),
+		attribute.String("finding.source", f.Source),
+		attribute.String("finding.type", f.Type),
+		attribute.Float64("finding.severity", f.Severity),
+		attribute.String("finding.severity_label", string(f.SeverityLabel)),
+		attribute.Int("finding.count", f.Service.Count),
+	}
+	if !f.UpdatedAt.IsZero() {
+		attrs = append(attrs, attribute.String("finding.updated_at", f.UpdatedAt.UTC().Format(time.RFC3339)))
+	}
+	return attrs
+}